@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"strings"
 )
 
 type charset string
@@ -169,3 +170,87 @@ nextRange:
 		panic("unhandled case: we have a hole in the byte ranges table")
 	}
 }
+
+// lookupRange is like lookup, but for any range of bytes, including the ones with an
+// odd start or an even end that are not in the byte ranges table: the partial tens
+// at both ends are handled as a single digit range, and lookup is used in between.
+//
+// Custom charsets equal to one of the default charsets (or ?d) are replaced by a
+// reference to it, so the ?4 slot is left available for other octets.
+func lookupRange(start uint8, end uint8) []string {
+	if start == 0 && end == 255 {
+		return masks0to255
+	}
+	if start == end {
+		return []string{strconv.Itoa(int(start))}
+	}
+	var masks []string
+	if start&1 == 1 {
+		last := end
+		if int(start)/10*10+9 < int(end) {
+			last = start/10*10 + 9
+		}
+		masks = append(masks, digitsMask(start, last))
+		if last == end {
+			return masks
+		}
+		start = last + 1
+	}
+	var tail string
+	if end&1 == 0 {
+		first := end / 10 * 10
+		if first < start {
+			first = start
+		}
+		tail = digitsMask(first, end)
+		if first == start {
+			return append(masks, tail)
+		}
+		end = first - 1
+	}
+	for _, mask := range lookup(start, end) {
+		if p := strings.IndexByte(mask, ','); p > 0 {
+			if m := charsetMask(mask[:p]); m != "" {
+				mask = strings.Replace(mask[p+1:], "?4", m, 1)
+			}
+		}
+		masks = append(masks, mask)
+	}
+	if tail != "" {
+		masks = append(masks, tail)
+	}
+	return masks
+}
+
+// charsetMask returns the mask of the builtin or default charset equal to cs, or ""
+// if none.
+func charsetMask(cs string) string {
+	switch charset(cs) {
+	case cs04:
+		return mask0to4
+	case cs05:
+		return mask0to5
+	case cs19:
+		return mask1to9
+	case "0123456789":
+		return mask0to9
+	}
+	return ""
+}
+
+// digitsMask returns the mask of the range [start, end] where start and end differ
+// only by the last digit.
+func digitsMask(start uint8, end uint8) string {
+	var prefix string
+	if start >= 10 {
+		prefix = strconv.Itoa(int(start / 10))
+	}
+	if start == end {
+		return prefix + strconv.Itoa(int(start%10))
+	}
+	digits := "0123456789"[start%10 : end%10+1]
+	if m := charsetMask(digits); m != "" {
+		return prefix + m
+	}
+	return digits + "," + prefix + "?4"
+}
@@ -35,70 +35,24 @@ var (
 //
 // Exemple value: 192.168.2.0/24.
 //
-// Errors returned (check with [errors.Is]): [ErrSyntax], [ErrNonZeroBits]
+// Errors returned (check with [errors.Is]): [ErrSyntax], [ErrNonZeroBits]. The
+// error is a [*ParseError] (check with [errors.As]).
 func ParseCIDR(s string) (IPv4Net, error) {
-	if len(s) > (3+1+3+1+3+1+3)+1+(2) {
-		return IPv4Net{}, ErrSyntax
-	}
-	ipStr, bitsStr, found := strings.Cut(s, "/")
-	if !found {
-		return IPv4Net{}, ErrSyntax
-	}
-	if len(bitsStr) > 1 && bitsStr[0] == '0' { // Disallow leading zero
-		return IPv4Net{}, ErrSyntax
-	}
-	var net IPv4Net
-	n, err := strconv.ParseUint(bitsStr, 10, 8)
+	net, err := parseCIDR(s)
 	if err != nil {
-		return IPv4Net{}, ErrSyntax
-	}
-	if n > 32 { // FIXME check spec
-		return IPv4Net{}, ErrSyntax
-	}
-	net.Bits = int(n)
-
-	ipParts := strings.SplitN(ipStr, ".", 5)
-	if len(ipParts) != 4 {
-		return IPv4Net{}, ErrSyntax
-	}
-
-	bits := net.Bits
-	errBits := false
-	for i := 0; i < 4; i++ {
-		if len(ipParts[i]) > 1 && ipParts[i][0] == '0' { // Disallow leading zero
-			return IPv4Net{}, ErrSyntax
-		}
-		if bits == 0 {
-			if ipParts[i] != "0" {
-				if _, err = strconv.ParseUint(ipParts[i], 10, 8); err != nil {
-					return IPv4Net{}, ErrSyntax
-				}
-				errBits = true
-			}
-		} else {
-			n, err = strconv.ParseUint(ipParts[i], 10, 8)
-			if err != nil {
-				return IPv4Net{}, ErrSyntax
-			}
-			if bits >= 8 {
-				net.IP[i] = byte(n)
-				bits -= 8
-			} else { // 1 to 7 bits
-				mask := (uint64(1) << (8 - bits)) - 1
-				if n&mask != 0 {
-					errBits = true
-					n = n &^ mask
-				}
-				net.IP[i] = byte(n)
-				bits = 0
-			}
-		}
+		return IPv4Net{}, err
 	}
+	return net, nil
+}
 
-	if errBits { // Error is delayed until full syntax has been checked
-		return IPv4Net{}, fmt.Errorf("%s: %w (%s expected)", s, ErrNonZeroBits, net)
+// parseCIDR is like [ParseCIDR] but also returns the canonical network along with
+// [ErrNonZeroBits].
+func parseCIDR(s string) (IPv4Net, error) {
+	net, err := scanCIDR(s)
+	if err != nil {
+		err.Fix = fixCIDR(err)
+		return net, err
 	}
-
 	return net, nil
 }
 
@@ -123,9 +77,10 @@ func cidr2hcmask(net IPv4Net) [4][]string {
 	return masks
 }
 
+const defaultCharsets = cs04 + "," + cs05 + "," + cs19 + ","
+
 func expand(ipmask [4][]string, cb func(mask string)) {
 	var bufferPattern [len(mask200to249)*4 + 3 + len("10?4")]byte
-	const defaultCharsets = cs04 + "," + cs05 + "," + cs19 + ","
 	var bufferCharsets [len(defaultCharsets) + 9 /* mask for ?4 with up to 9 digits */ + 1 + cap(bufferPattern)]byte
 	charsets := append(bufferCharsets[:0], defaultCharsets...)
 
@@ -139,30 +94,67 @@ func expandRec(charsets []byte, pattern []byte, ipmask [][]string, cb func(mask
 	if len(pattern) > 0 {
 		pattern = append(pattern, '.')
 	}
-	last := len(ipmask) == 1
-	masks := ipmask[0]
-	for i := 0; i < len(masks); i++ {
+	for _, mask := range ipmask[0] {
 		charsets := charsets
-		pattern := pattern
-
-		mask := masks[i]
 		if p := strings.IndexByte(mask, ','); p > 0 {
-			charsets = append(charsets, mask[:p+1]...)
+			custom := charsets[len(defaultCharsets):]
+			if len(custom) > 0 && string(custom) != mask[:p+1] {
+				// The custom charset ?4 is already used by a previous octet
+				// with other digits: enumerate the digits of this one
+				q := strings.Index(mask, "?4")
+				for j := 0; j < p; j++ {
+					expandNext(charsets, append(append(append(pattern, mask[p+1:q]...), mask[j]), mask[q+2:]...), ipmask[1:], cb)
+				}
+				continue
+			}
+			if len(custom) == 0 {
+				charsets = append(charsets, mask[:p+1]...)
+			}
 			mask = mask[p+1:]
 		}
-		pattern = append(pattern, mask...)
-		if last {
-			cb(string(append(charsets, pattern...)))
-		} else {
-			expandRec(charsets, pattern, ipmask[1:], cb)
-		}
+		expandNext(charsets, append(pattern, mask...), ipmask[1:], cb)
 	}
 }
 
+func expandNext(charsets []byte, pattern []byte, ipmask [][]string, cb func(mask string)) {
+	if len(ipmask) == 0 {
+		cb(string(append(charsets, pattern...)))
+		return
+	}
+	expandRec(charsets, pattern, ipmask, cb)
+}
+
 func CIDR2HCMaskFunc(net IPv4Net, cb func(mask string)) {
 	expand(cidr2hcmask(net), cb)
 }
 
+// CIDR2HCMaskExcludeFunc is like [CIDR2HCMaskFunc] but the masks cover only the
+// addresses of net that are not in one of the exclude networks.
+func CIDR2HCMaskExcludeFunc(net IPv4Net, exclude []IPv4Net, cb func(mask string)) {
+	s := NewIPv4SetFromNets(net).Difference(NewIPv4SetFromNets(exclude...))
+	Set2HCMaskFunc(s, cb)
+}
+
+// CIDR2HCMaskHostsFunc is like [CIDR2HCMaskFunc] but the masks cover only the host
+// addresses of the subnets of net of prefix length subnetBits: the network and
+// broadcast addresses of each subnet are excluded. A subnetBits lower than
+// net.Bits (such as 0) means net.Bits. See [IPv4Set.Hosts].
+//
+// Exemple: 10.1.0.0/16 with subnetBits 24 excludes 10.1.x.0 and 10.1.x.255.
+//
+// Errors returned (check with [errors.Is]): [ErrTooManySubnets]
+func CIDR2HCMaskHostsFunc(net IPv4Net, subnetBits int, cb func(mask string)) error {
+	if subnetBits < net.Bits {
+		subnetBits = net.Bits
+	}
+	s, err := NewIPv4SetFromNets(net).Hosts(subnetBits)
+	if err != nil {
+		return err
+	}
+	Set2HCMaskFunc(s, cb)
+	return nil
+}
+
 func CIDR2HCMask(net IPv4Net) []string {
 	var masks []string
 	CIDR2HCMaskFunc(net, func(mask string) {
@@ -171,7 +163,14 @@ func CIDR2HCMask(net IPv4Net) []string {
 	return masks
 }
 
-func CIDR2HCMaskWrite(net IPv4Net, w io.Writer) (err error) {
+func CIDR2HCMaskWrite(net IPv4Net, w io.Writer) error {
+	return writeMasks(w, func(cb func(string)) {
+		CIDR2HCMaskFunc(net, cb)
+	})
+}
+
+// writeMasks writes to w each mask produced by gen, one per line.
+func writeMasks(w io.Writer, gen func(cb func(mask string))) (err error) {
 	defer func() {
 		if r := recover(); r != nil {
 			switch r := r.(type) {
@@ -182,7 +181,7 @@ func CIDR2HCMaskWrite(net IPv4Net, w io.Writer) (err error) {
 			}
 		}
 	}()
-	CIDR2HCMaskFunc(net, func(mask string) {
+	gen(func(mask string) {
 		_, err := w.Write([]byte(mask + "\n"))
 		if err != nil {
 			panic(err)
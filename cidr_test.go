@@ -1,8 +1,10 @@
 package cidr2hcmask_test
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"net/netip"
 	"os"
 	"regexp"
 	"strconv"
@@ -237,3 +239,103 @@ func TestCIDR2HCMaskAll(t *testing.T) {
 		t.Logf("Unexpected low count of patterns produced: got %d, expected %d", patternsCount, patternsCountExpected)
 	}
 }
+
+func TestCIDR2HCMaskExclude(t *testing.T) {
+	net := mustParseCIDR("10.1.0.0/16")
+	exclude := []cidr2hcmask.IPv4Net{
+		mustParseCIDR("10.1.5.0/24"),
+		mustParseCIDR("10.1.200.0/24"),
+		mustParseCIDR("10.1.7.1/32"),
+		mustParseCIDR("10.1.255.255/32"),
+		mustParseCIDR("10.2.0.0/16"), // Outside
+	}
+	count := 0
+	seen := make(map[string]bool)
+	cidr2hcmask.CIDR2HCMaskExcludeFunc(net, exclude, func(mask string) {
+		count++
+		HCMaskExpand(mask, func(b []byte) {
+			ip, err := netip.ParseAddr(string(b))
+			if err != nil {
+				t.Fatalf("%s: %q: %v", mask, b, err)
+			}
+			if !net.Contains(ip.As4()) {
+				t.Errorf("%s: %q: out of network", mask, b)
+			}
+			for _, ex := range exclude {
+				if ex.Contains(ip.As4()) {
+					t.Errorf("%s: %q: excluded by %s", mask, b, ex)
+				}
+			}
+			if seen[string(b)] {
+				t.Errorf("%s: %q: duplicate", mask, b)
+			}
+			seen[string(b)] = true
+		})
+	})
+	if len(seen) != 65536-2*256-2 {
+		t.Errorf("got %d addresses", len(seen))
+	}
+	t.Log(count, "masks")
+	if count > 51 {
+		t.Errorf("too many masks: %d", count)
+	}
+}
+
+func TestCIDR2HCMaskHosts(t *testing.T) {
+	for _, tc := range []struct {
+		net        string
+		subnetBits int
+		size       int
+		maxMasks   int
+	}{
+		{"192.168.1.0/24", 0, 254, 5},
+		{"10.1.0.0/16", 24, 256 * 254, 25},
+		{"10.1.0.0/16", 16, 65534, 35},
+		{"10.1.0.0/16", 8, 65534, 35}, // subnetBits < net.Bits
+		{"10.1.2.0/30", 0, 2, 1},
+		{"10.1.2.0/31", 0, 2, 1},
+		{"10.1.2.3/32", 0, 1, 1},
+		{"172.16.0.0/22", 26, 16 * 62, 43},
+	} {
+		net := mustParseCIDR(tc.net)
+		subnetBits := tc.subnetBits
+		if subnetBits < net.Bits {
+			subnetBits = net.Bits
+		}
+		count := 0
+		seen := make(map[string]bool)
+		err := cidr2hcmask.CIDR2HCMaskHostsFunc(net, tc.subnetBits, func(mask string) {
+			count++
+			HCMaskExpand(mask, func(b []byte) {
+				ip, err := netip.ParseAddr(string(b))
+				if err != nil {
+					t.Fatalf("%s: %q: %v", mask, b, err)
+				}
+				if !net.Contains(ip.As4()) {
+					t.Errorf("%s: %q: out of network", mask, b)
+				}
+				if subnetBits < 31 {
+					hostMask := uint32(1)<<(32-subnetBits) - 1
+					ip4 := ip.As4()
+					if host := binary.BigEndian.Uint32(ip4[:]) & hostMask; host == 0 || host == hostMask {
+						t.Errorf("%s: %q: not a host address", mask, b)
+					}
+				}
+				if seen[string(b)] {
+					t.Errorf("%s: %q: duplicate", mask, b)
+				}
+				seen[string(b)] = true
+			})
+		})
+		if err != nil {
+			t.Fatalf("%s /%d: %v", tc.net, tc.subnetBits, err)
+		}
+		if len(seen) != tc.size {
+			t.Errorf("%s /%d: got %d addresses, expected %d", tc.net, tc.subnetBits, len(seen), tc.size)
+		}
+		t.Logf("%s /%d: %d masks", tc.net, tc.subnetBits, count)
+		if count > tc.maxMasks {
+			t.Errorf("%s /%d: too many masks: %d", tc.net, tc.subnetBits, count)
+		}
+	}
+}
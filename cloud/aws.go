@@ -0,0 +1,59 @@
+package cloud
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/dolmen-go/cidr2hcmask/internal/match"
+)
+
+// awsIPRanges is the format of https://ip-ranges.amazonaws.com/ip-ranges.json.
+//
+// Doc: https://docs.aws.amazon.com/vpc/latest/userguide/aws-ip-ranges.html
+type awsIPRanges struct {
+	Prefixes []struct {
+		IPPrefix string `json:"ip_prefix"`
+		Region   string `json:"region"`
+		Service  string `json:"service"`
+	} `json:"prefixes"`
+	IPv6Prefixes []struct {
+		IPv6Prefix string `json:"ipv6_prefix"`
+		Region     string `json:"region"`
+		Service    string `json:"service"`
+	} `json:"ipv6_prefixes"`
+}
+
+// ReadAWS imports the prefixes of an AWS ip-ranges.json file matching f. The file
+// has no tags: Filter.Tags is not supported.
+//
+// Note that in this file the prefixes of each service are also listed under the
+// "AMAZON" service.
+func ReadAWS(r io.Reader, f Filter) (Prefixes, error) {
+	if err := checkFilter(f, true, true, false); err != nil {
+		return Prefixes{}, fmt.Errorf("AWS: %w", err)
+	}
+	var ranges awsIPRanges
+	if err := json.NewDecoder(r).Decode(&ranges); err != nil {
+		return Prefixes{}, fmt.Errorf("AWS: %w", err)
+	}
+
+	var p Prefixes
+	for _, prefix := range ranges.Prefixes {
+		if !match.Glob(f.Regions, prefix.Region) || !match.Glob(f.Services, prefix.Service) {
+			continue
+		}
+		if err := p.addIPv4(prefix.IPPrefix); err != nil {
+			return Prefixes{}, fmt.Errorf("AWS: %w", err)
+		}
+	}
+	for _, prefix := range ranges.IPv6Prefixes {
+		if !match.Glob(f.Regions, prefix.Region) || !match.Glob(f.Services, prefix.Service) {
+			continue
+		}
+		if err := p.addIPv6(prefix.IPv6Prefix); err != nil {
+			return Prefixes{}, fmt.Errorf("AWS: %w", err)
+		}
+	}
+	return p, nil
+}
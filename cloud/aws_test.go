@@ -0,0 +1,87 @@
+package cloud_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask/cloud"
+)
+
+const awsIPRanges = `{
+  "syncToken": "1700000000",
+  "createDate": "2023-11-14-22-13-20",
+  "prefixes": [
+    {"ip_prefix": "3.5.136.0/22", "region": "eu-central-1", "service": "AMAZON", "network_border_group": "eu-central-1"},
+    {"ip_prefix": "3.5.136.0/22", "region": "eu-central-1", "service": "S3", "network_border_group": "eu-central-1"},
+    {"ip_prefix": "3.64.0.0/12", "region": "eu-central-1", "service": "AMAZON", "network_border_group": "eu-central-1"},
+    {"ip_prefix": "3.64.0.0/12", "region": "eu-central-1", "service": "EC2", "network_border_group": "eu-central-1"},
+    {"ip_prefix": "3.72.0.0/14", "region": "eu-central-1", "service": "EC2", "network_border_group": "eu-central-1"},
+    {"ip_prefix": "18.153.0.0/16", "region": "eu-central-2", "service": "EC2", "network_border_group": "eu-central-2"},
+    {"ip_prefix": "3.80.0.0/12", "region": "us-east-1", "service": "EC2", "network_border_group": "us-east-1"}
+  ],
+  "ipv6_prefixes": [
+    {"ipv6_prefix": "2a05:d014::/36", "region": "eu-central-1", "service": "EC2", "network_border_group": "eu-central-1"},
+    {"ipv6_prefix": "2a05:d014:800::/40", "region": "eu-central-1", "service": "EC2", "network_border_group": "eu-central-1"},
+    {"ipv6_prefix": "2600:1f18::/33", "region": "us-east-1", "service": "EC2", "network_border_group": "us-east-1"}
+  ]
+}`
+
+func TestReadAWS(t *testing.T) {
+	for _, tc := range []struct {
+		filter     cloud.Filter
+		ipv4, ipv6 string
+	}{
+		{
+			cloud.Filter{Regions: []string{"eu-central-1"}, Services: []string{"EC2"}},
+			"[3.64.0.0/12 3.72.0.0/14]",
+			"[2a05:d014::/36 2a05:d014:800::/40]",
+		},
+		{
+			cloud.Filter{Regions: []string{"EU-*"}, Services: []string{"ec2", "S3"}},
+			"[3.5.136.0/22 3.64.0.0/12 3.72.0.0/14 18.153.0.0/16]",
+			"[2a05:d014::/36 2a05:d014:800::/40]",
+		},
+		{
+			cloud.Filter{Regions: []string{"us-east-1"}},
+			"[3.80.0.0/12]",
+			"[2600:1f18::/33]",
+		},
+	} {
+		p, err := cloud.ReadAWS(strings.NewReader(awsIPRanges), tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(p.IPv4); got != tc.ipv4 {
+			t.Errorf("%+v: IPv4: got %s, expected %s", tc.filter, got, tc.ipv4)
+		}
+		if got := fmt.Sprint(p.IPv6); got != tc.ipv6 {
+			t.Errorf("%+v: IPv6: got %s, expected %s", tc.filter, got, tc.ipv6)
+		}
+	}
+}
+
+func TestReadAWSMerge(t *testing.T) {
+	p, err := cloud.ReadAWS(strings.NewReader(awsIPRanges), cloud.Filter{Regions: []string{"eu-central-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(p.IPv4Set().Nets()); got != "[3.5.136.0/22 3.64.0.0/12]" {
+		t.Errorf("IPv4: got %s", got)
+	}
+	if got := fmt.Sprint(p.AggregateIPv6()); got != "[2a05:d014::/36]" {
+		t.Errorf("IPv6: got %s", got)
+	}
+}
+
+func TestReadAWSError(t *testing.T) {
+	for _, tc := range []string{
+		`{"prefixes": [{"ip_prefix": "3.5.136.1/22"}]}`,
+		`{"ipv6_prefixes": [{"ipv6_prefix": "3.5.136.0/22"}]}`,
+		`[]`,
+	} {
+		if _, err := cloud.ReadAWS(strings.NewReader(tc), cloud.Filter{}); err == nil {
+			t.Errorf("%s: error expected", tc)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+// Package cloud imports the IP address ranges that cloud providers publish (AWS,
+// Azure, GCP, OCI, Cloudflare), selected by region, service or tag, through a
+// registry of importers by provider name.
+package cloud
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dolmen-go/cidr2hcmask"
+	"github.com/dolmen-go/cidr2hcmask/internal/match"
+)
+
+// Filter selects the prefixes of a published ranges file.
+//
+// Each field is a list of glob patterns (see [path.Match]) matched without regard
+// to case. A prefix is selected if it matches one of the patterns of each
+// non-empty list. A provider whose file has no such property returns
+// [ErrUnsupportedFilter] if the list is not empty.
+type Filter struct {
+	Regions  []string
+	Services []string
+	Tags     []string
+}
+
+// ErrUnsupportedFilter is returned for a [Filter] on a property that the file of
+// the provider doesn't have.
+var ErrUnsupportedFilter = errors.New("unsupported filter")
+
+// checkFilter returns [ErrUnsupportedFilter] if f selects by a property that the
+// file doesn't have.
+func checkFilter(f Filter, hasRegions, hasServices, hasTags bool) error {
+	for _, p := range []struct {
+		name string
+		has  bool
+		list []string
+	}{
+		{"regions", hasRegions, f.Regions},
+		{"services", hasServices, f.Services},
+		{"tags", hasTags, f.Tags},
+	} {
+		if !p.has && len(p.list) > 0 {
+			return fmt.Errorf("%w: the file has no %s", ErrUnsupportedFilter, p.name)
+		}
+	}
+	return nil
+}
+
+// matchAnyOf reports whether one of values matches one of patterns.
+func matchAnyOf(patterns []string, values []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, value := range values {
+		if match.Glob(patterns, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Prefixes are the networks imported from a ranges file.
+type Prefixes struct {
+	IPv4 []cidr2hcmask.IPv4Net
+	IPv6 []cidr2hcmask.IPv6Net
+}
+
+// IPv4Set returns the set of the IPv4 addresses, with overlapping prefixes merged.
+func (p Prefixes) IPv4Set() cidr2hcmask.IPv4Set {
+	return cidr2hcmask.NewIPv4SetFromNets(p.IPv4...)
+}
+
+// AggregateIPv6 returns the IPv6 prefixes, with overlapping prefixes merged.
+func (p Prefixes) AggregateIPv6() []cidr2hcmask.IPv6Net {
+	return cidr2hcmask.AggregateIPv6(p.IPv6)
+}
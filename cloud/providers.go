@@ -0,0 +1,182 @@
+package cloud
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dolmen-go/cidr2hcmask"
+	"github.com/dolmen-go/cidr2hcmask/internal/match"
+)
+
+// add parses a prefix (IPv4 or IPv6) and appends it to p.
+func (p *Prefixes) add(prefix string) error {
+	if strings.Contains(prefix, ":") {
+		return p.addIPv6(prefix)
+	}
+	return p.addIPv4(prefix)
+}
+
+func (p *Prefixes) addIPv4(prefix string) error {
+	net, err := cidr2hcmask.ParseCIDR(prefix)
+	if err != nil {
+		return fmt.Errorf("%q: %w", prefix, err)
+	}
+	p.IPv4 = append(p.IPv4, net)
+	return nil
+}
+
+func (p *Prefixes) addIPv6(prefix string) error {
+	net, err := cidr2hcmask.ParseIPv6CIDR(prefix)
+	if err != nil {
+		return fmt.Errorf("%q: %w", prefix, err)
+	}
+	p.IPv6 = append(p.IPv6, net)
+	return nil
+}
+
+// azureServiceTags is the format of the Azure IP Ranges and Service Tags JSON files.
+//
+// Doc: https://learn.microsoft.com/en-us/azure/virtual-network/service-tags-overview
+type azureServiceTags struct {
+	Values []struct {
+		Name       string `json:"name"`
+		Properties struct {
+			Region          string   `json:"region"`
+			SystemService   string   `json:"systemService"`
+			AddressPrefixes []string `json:"addressPrefixes"`
+		} `json:"properties"`
+	} `json:"values"`
+}
+
+// ReadAzure imports the prefixes of an Azure Service Tags JSON file matching f.
+// Filter.Tags matches the name of the service tag (ex: AzureCloud.westeurope).
+//
+// Note that the tags without region list the prefixes of all regions.
+func ReadAzure(r io.Reader, f Filter) (Prefixes, error) {
+	var tags azureServiceTags
+	if err := json.NewDecoder(r).Decode(&tags); err != nil {
+		return Prefixes{}, fmt.Errorf("Azure: %w", err)
+	}
+	var p Prefixes
+	for _, v := range tags.Values {
+		if !match.Glob(f.Regions, v.Properties.Region) || !match.Glob(f.Services, v.Properties.SystemService) || !match.Glob(f.Tags, v.Name) {
+			continue
+		}
+		for _, prefix := range v.Properties.AddressPrefixes {
+			if err := p.add(prefix); err != nil {
+				return Prefixes{}, fmt.Errorf("Azure: %w", err)
+			}
+		}
+	}
+	return p, nil
+}
+
+// gcpCloud is the format of https://www.gstatic.com/ipranges/cloud.json.
+//
+// Doc: https://cloud.google.com/compute/docs/faq#find_ip_range
+type gcpCloud struct {
+	Prefixes []struct {
+		IPv4Prefix string `json:"ipv4Prefix"`
+		IPv6Prefix string `json:"ipv6Prefix"`
+		Service    string `json:"service"`
+		Scope      string `json:"scope"`
+	} `json:"prefixes"`
+}
+
+// ReadGCP imports the prefixes of a GCP cloud.json file matching f. The region is
+// the "scope" of the prefix. The file has no tags: Filter.Tags is not supported.
+func ReadGCP(r io.Reader, f Filter) (Prefixes, error) {
+	if err := checkFilter(f, true, true, false); err != nil {
+		return Prefixes{}, fmt.Errorf("GCP: %w", err)
+	}
+	var ranges gcpCloud
+	if err := json.NewDecoder(r).Decode(&ranges); err != nil {
+		return Prefixes{}, fmt.Errorf("GCP: %w", err)
+	}
+	var p Prefixes
+	for _, prefix := range ranges.Prefixes {
+		if !match.Glob(f.Regions, prefix.Scope) || !match.Glob(f.Services, prefix.Service) {
+			continue
+		}
+		var err error
+		if prefix.IPv4Prefix != "" {
+			err = p.addIPv4(prefix.IPv4Prefix)
+		} else {
+			err = p.addIPv6(prefix.IPv6Prefix)
+		}
+		if err != nil {
+			return Prefixes{}, fmt.Errorf("GCP: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// ociPublicIPRanges is the format of https://docs.oracle.com/en-us/iaas/tools/public_ip_ranges.json.
+//
+// Doc: https://docs.oracle.com/en-us/iaas/Content/General/Concepts/addressranges.htm
+type ociPublicIPRanges struct {
+	Regions []struct {
+		Region string `json:"region"`
+		CIDRs  []struct {
+			CIDR string   `json:"cidr"`
+			Tags []string `json:"tags"`
+		} `json:"cidrs"`
+	} `json:"regions"`
+}
+
+// ReadOCI imports the prefixes of an Oracle Cloud public_ip_ranges.json file matching
+// f. Filter.Tags matches one of the tags of the prefix (ex: OCI, OSN, OBJECT_STORAGE).
+// The file has no services: Filter.Services is not supported.
+func ReadOCI(r io.Reader, f Filter) (Prefixes, error) {
+	if err := checkFilter(f, true, false, true); err != nil {
+		return Prefixes{}, fmt.Errorf("OCI: %w", err)
+	}
+	var ranges ociPublicIPRanges
+	if err := json.NewDecoder(r).Decode(&ranges); err != nil {
+		return Prefixes{}, fmt.Errorf("OCI: %w", err)
+	}
+	var p Prefixes
+	for _, region := range ranges.Regions {
+		if !match.Glob(f.Regions, region.Region) {
+			continue
+		}
+		for _, cidr := range region.CIDRs {
+			if !matchAnyOf(f.Tags, cidr.Tags) {
+				continue
+			}
+			if err := p.add(cidr.CIDR); err != nil {
+				return Prefixes{}, fmt.Errorf("OCI: %w", err)
+			}
+		}
+	}
+	return p, nil
+}
+
+// ReadCloudflare imports the prefixes of the Cloudflare plain text lists
+// (https://www.cloudflare.com/ips-v4 and https://www.cloudflare.com/ips-v6): one
+// prefix per line.
+//
+// The lists have no regions, services or tags: the filter must be empty.
+func ReadCloudflare(r io.Reader, f Filter) (Prefixes, error) {
+	if err := checkFilter(f, false, false, false); err != nil {
+		return Prefixes{}, fmt.Errorf("Cloudflare: %w", err)
+	}
+	var p Prefixes
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if err := p.add(line); err != nil {
+			return Prefixes{}, fmt.Errorf("Cloudflare: %w", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Prefixes{}, fmt.Errorf("Cloudflare: %w", err)
+	}
+	return p, nil
+}
@@ -0,0 +1,162 @@
+package cloud_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask/cloud"
+)
+
+const azureServiceTags = `{
+  "changeNumber": 300,
+  "cloud": "Public",
+  "values": [
+    {
+      "name": "AzureCloud.westeurope",
+      "id": "AzureCloud.westeurope",
+      "properties": {
+        "changeNumber": 100,
+        "region": "westeurope",
+        "regionId": 18,
+        "platform": "Azure",
+        "systemService": "",
+        "addressPrefixes": ["13.69.0.0/17", "13.73.128.0/18", "2603:1020:200::/46"]
+      }
+    },
+    {
+      "name": "Storage.WestEurope",
+      "id": "Storage.WestEurope",
+      "properties": {
+        "region": "westeurope",
+        "systemService": "AzureStorage",
+        "addressPrefixes": ["13.69.40.0/21", "20.38.108.0/23"]
+      }
+    },
+    {
+      "name": "Storage.NorthEurope",
+      "id": "Storage.NorthEurope",
+      "properties": {
+        "region": "northeurope",
+        "systemService": "AzureStorage",
+        "addressPrefixes": ["13.70.192.0/18"]
+      }
+    }
+  ]
+}`
+
+const gcpCloud = `{
+  "syncToken": "1700000000000",
+  "creationTime": "2023-11-14T22:13:20.000000",
+  "prefixes": [
+    {"ipv4Prefix": "34.1.208.0/20", "service": "Google Cloud", "scope": "africa-south1"},
+    {"ipv4Prefix": "34.34.128.0/18", "service": "Google Cloud", "scope": "europe-west1"},
+    {"ipv6Prefix": "2600:1900:4010::/44", "service": "Google Cloud", "scope": "europe-west1"}
+  ]
+}`
+
+const ociPublicIPRanges = `{
+  "last_updated_timestamp": "2023-11-14T22:13:20.000000",
+  "regions": [
+    {
+      "region": "eu-frankfurt-1",
+      "cidrs": [
+        {"cidr": "130.61.0.0/16", "tags": ["OCI"]},
+        {"cidr": "134.70.24.0/21", "tags": ["OSN", "OBJECT_STORAGE"]}
+      ]
+    },
+    {
+      "region": "us-ashburn-1",
+      "cidrs": [
+        {"cidr": "129.213.0.0/16", "tags": ["OCI"]}
+      ]
+    }
+  ]
+}`
+
+const cloudflareIPs = `173.245.48.0/20
+103.21.244.0/22
+2400:cb00::/32
+`
+
+func TestProviders(t *testing.T) {
+	for _, tc := range []struct {
+		provider   string
+		data       string
+		filter     cloud.Filter
+		ipv4, ipv6 string
+	}{
+		{"azure", azureServiceTags, cloud.Filter{Regions: []string{"westeurope"}},
+			"[13.69.0.0/17 13.73.128.0/18 13.69.40.0/21 20.38.108.0/23]", "[2603:1020:200::/46]"},
+		{"azure", azureServiceTags, cloud.Filter{Services: []string{"AzureStorage"}},
+			"[13.69.40.0/21 20.38.108.0/23 13.70.192.0/18]", "[]"},
+		{"azure", azureServiceTags, cloud.Filter{Tags: []string{"Storage.*Europe"}, Regions: []string{"north*"}},
+			"[13.70.192.0/18]", "[]"},
+		{"gcp", gcpCloud, cloud.Filter{Regions: []string{"europe-*"}},
+			"[34.34.128.0/18]", "[2600:1900:4010::/44]"},
+		{"gcp", gcpCloud, cloud.Filter{Services: []string{"google cloud"}},
+			"[34.1.208.0/20 34.34.128.0/18]", "[2600:1900:4010::/44]"},
+		{"oci", ociPublicIPRanges, cloud.Filter{Regions: []string{"eu-frankfurt-1"}},
+			"[130.61.0.0/16 134.70.24.0/21]", "[]"},
+		{"oci", ociPublicIPRanges, cloud.Filter{Tags: []string{"OCI"}},
+			"[130.61.0.0/16 129.213.0.0/16]", "[]"},
+		{"cloudflare", cloudflareIPs, cloud.Filter{},
+			"[173.245.48.0/20 103.21.244.0/22]", "[2400:cb00::/32]"},
+	} {
+		imp := cloud.Lookup(tc.provider)
+		if imp == nil {
+			t.Fatalf("%s: not registered", tc.provider)
+		}
+		p, err := imp.Import(strings.NewReader(tc.data), tc.filter)
+		if err != nil {
+			t.Errorf("%s: %v", tc.provider, err)
+			continue
+		}
+		if got := fmt.Sprint(p.IPv4); got != tc.ipv4 {
+			t.Errorf("%s %+v: IPv4: got %s, expected %s", tc.provider, tc.filter, got, tc.ipv4)
+		}
+		if got := fmt.Sprint(p.IPv6); got != tc.ipv6 {
+			t.Errorf("%s %+v: IPv6: got %s, expected %s", tc.provider, tc.filter, got, tc.ipv6)
+		}
+	}
+}
+
+func TestProvidersUnsupportedFilter(t *testing.T) {
+	for _, tc := range []struct {
+		provider string
+		data     string
+		filter   cloud.Filter
+	}{
+		{"aws", awsIPRanges, cloud.Filter{Tags: []string{"*"}}},
+		{"gcp", gcpCloud, cloud.Filter{Tags: []string{"*"}}},
+		{"oci", ociPublicIPRanges, cloud.Filter{Services: []string{"OCI"}}},
+		{"cloudflare", cloudflareIPs, cloud.Filter{Regions: []string{"*"}}},
+		{"cloudflare", cloudflareIPs, cloud.Filter{Services: []string{"*"}}},
+	} {
+		_, err := cloud.Lookup(tc.provider).Import(strings.NewReader(tc.data), tc.filter)
+		if !errors.Is(err, cloud.ErrUnsupportedFilter) {
+			t.Errorf("%s %+v: got %v, expected ErrUnsupportedFilter", tc.provider, tc.filter, err)
+		} else {
+			t.Log(err)
+		}
+	}
+}
+
+func TestProvidersRegister(t *testing.T) {
+	for _, name := range []string{"aws", "azure", "cloudflare", "gcp", "oci"} {
+		if cloud.Lookup(name) == nil {
+			t.Errorf("%s: not registered", name)
+		}
+	}
+	if cloud.Lookup("unknown") != nil {
+		t.Error("nil expected")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("panic expected")
+		}
+	}()
+	cloud.Register("aws", cloud.ImporterFunc(cloud.ReadCloudflare))
+}
@@ -0,0 +1,64 @@
+package cloud
+
+import (
+	"io"
+	"sort"
+	"sync"
+)
+
+// Importer reads the published ranges file of a provider and returns the prefixes
+// matching a filter.
+type Importer interface {
+	Import(r io.Reader, f Filter) (Prefixes, error)
+}
+
+// ImporterFunc adapts a function to the [Importer] interface.
+type ImporterFunc func(r io.Reader, f Filter) (Prefixes, error)
+
+// Import implements [Importer].
+func (fn ImporterFunc) Import(r io.Reader, f Filter) (Prefixes, error) {
+	return fn(r, f)
+}
+
+var (
+	importersMu sync.RWMutex
+	importers   = make(map[string]Importer)
+)
+
+// Register makes an importer available by provider name. It panics if the name is
+// already registered.
+func Register(name string, imp Importer) {
+	importersMu.Lock()
+	defer importersMu.Unlock()
+	if _, dup := importers[name]; dup {
+		panic("cloud: Register called twice for provider " + name)
+	}
+	importers[name] = imp
+}
+
+// Lookup returns the importer registered for the provider name, or nil.
+func Lookup(name string) Importer {
+	importersMu.RLock()
+	defer importersMu.RUnlock()
+	return importers[name]
+}
+
+// Providers returns the sorted list of the names of the registered providers.
+func Providers() []string {
+	importersMu.RLock()
+	defer importersMu.RUnlock()
+	names := make([]string, 0, len(importers))
+	for name := range importers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register("aws", ImporterFunc(ReadAWS))
+	Register("azure", ImporterFunc(ReadAzure))
+	Register("gcp", ImporterFunc(ReadGCP))
+	Register("oci", ImporterFunc(ReadOCI))
+	Register("cloudflare", ImporterFunc(ReadCloudflare))
+}
@@ -1,24 +1,1057 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"net/netip"
 	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/dolmen-go/cidr2hcmask"
+	"github.com/dolmen-go/cidr2hcmask/cloud"
+	"github.com/dolmen-go/cidr2hcmask/firewall"
+	"github.com/dolmen-go/cidr2hcmask/geoip"
+	"github.com/dolmen-go/cidr2hcmask/ip2asn"
+	"github.com/dolmen-go/cidr2hcmask/rir"
+	"github.com/dolmen-go/cidr2hcmask/scan"
 )
 
+// listFlag is a flag that can be repeated and whose values are comma-separated lists.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+// argsFlag is a flag that can be repeated and whose values are not split.
+type argsFlag []string
+
+func (a *argsFlag) String() string {
+	return strings.Join(*a, " ")
+}
+
+func (a *argsFlag) Set(s string) error {
+	*a = append(*a, s)
+	return nil
+}
+
+var lenient bool
+
+// The standard streams, replaced by the tests.
+var (
+	stdin  io.Reader = os.Stdin
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
+// outputFormat is an output format of targets.
+type outputFormat struct {
+	begin    func(w io.Writer) error // optional
+	write    func(t target, w io.Writer) error
+	end      func(w io.Writer) error // optional
+	ipv4Only bool
+	comments bool // # comments (headers, invalid lines) are allowed in the output
+}
+
+// ipv4Format returns the write function of an output format for IPv4 targets.
+func ipv4Format(write func(set cidr2hcmask.IPv4Set, w io.Writer) error) func(t target, w io.Writer) error {
+	return func(t target, w io.Writer) error { return write(t.set(), w) }
+}
+
+// formats are the constructors of the output formats, called once per run.
+var formats = map[string]func() outputFormat{
+	"hcmask": func() outputFormat {
+		return outputFormat{write: func(t target, w io.Writer) error { return t.write(w) }, comments: true}
+	},
+	"john": func() outputFormat {
+		return outputFormat{write: ipv4Format(cidr2hcmask.Set2JohnMaskWrite), ipv4Only: true, comments: true}
+	},
+	"regex": func() outputFormat {
+		return outputFormat{write: ipv4Format(cidr2hcmask.Set2RegexpWrite), ipv4Only: true}
+	},
+	"enumerate": func() outputFormat {
+		return outputFormat{write: ipv4Format(cidr2hcmask.Set2WordlistWrite), ipv4Only: true}
+	},
+	"ndjson": func() outputFormat {
+		return outputFormat{write: writeNDJSON}
+	},
+	"hashcat-sh": func() outputFormat {
+		return outputFormat{
+			begin:    func(w io.Writer) error { _, err := io.WriteString(w, "#!/bin/sh\n"); return err },
+			write:    writeHashcatScript,
+			comments: true,
+		}
+	},
+	"hashcat-json": func() outputFormat {
+		return outputFormat{
+			begin: func(w io.Writer) error { _, err := io.WriteString(w, "["); return err },
+			write: hashcatJSONWriter(),
+			end:   func(w io.Writer) error { _, err := io.WriteString(w, "\n]\n"); return err },
+		}
+	},
+}
+
 func main() {
-	if len(os.Args) == 1 {
-		fmt.Println("usage:", os.Args[0], "<ip/bits>")
-		os.Exit(1)
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command with the arguments args and returns the exit code.
+func run(args []string) int {
+	hashcat = hashcatCommand{}
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage:", os.Args[0], "[options] <target>... | -f <file> | -")
+		fmt.Fprintln(fs.Output(), "      ", os.Args[0], "[options] -import <provider>:<file> [-4 | -6] [-region <region>] [-service <service>] [-tag <tag>]")
+		fmt.Fprintln(fs.Output(), "      ", os.Args[0], "[options] -rir <delegated stats file> [-country <cc>] [-registry <rir>] [-status <status>]")
+		fmt.Fprintln(fs.Output(), "      ", os.Args[0], "[options] -geoip <blocks file>,<locations file> [-country <cc>] [-continent <code>] [-geoname <id>]")
+		fmt.Fprintln(fs.Output(), "      ", os.Args[0], "[options] -ip2asn <tsv file> [-asn <asn>] [-as-description <regexp>]")
+		fmt.Fprintln(fs.Output(), "      ", os.Args[0], "[options] -nmap <xml file> | -masscan <json file> [-port <port>]")
+		fmt.Fprintln(fs.Output(), "      ", os.Args[0], "[options] -iptables <file> | -ipset <file> | -nft <file> [-name <chain or set>]")
+		fmt.Fprintln(fs.Output(), "target: <ip> | <ip/bits> | <ip/netmask> | '<ip> <wildcard>' | <first ip>-<last ip> | <nmap octets: 10.0-3.*.1> | <@preset,...> | <ipv6/bits>")
+		fmt.Fprintln(fs.Output(), "presets:", "@"+strings.Join(cidr2hcmask.PresetNames(), ", @"))
+		fmt.Fprintln(fs.Output(), "providers:", strings.Join(cloud.Providers(), ", "))
+		fs.PrintDefaults()
+	}
+	fs.BoolVar(&lenient, "lenient", false, "accept abbreviated networks (10/8), and clear host bits with a warning")
+	var format string
+	fs.StringVar(&format, "format", "hcmask", "output `format`: hcmask, john (John the Ripper masks), regex (anchored regular expression), enumerate (all the addresses), ndjson (hashcat masks with metadata), hashcat-sh (shell script of hashcat commands), hashcat-json (hashcat argv lists)")
+	fs.StringVar(&hashcat.mode, "hash-mode", "", "hash `mode` for -format hashcat-sh and hashcat-json (hashcat -m)")
+	fs.StringVar(&hashcat.hashFile, "hash-file", "", "hash `file` for -format hashcat-sh and hashcat-json")
+	fs.Var((*argsFlag)(&hashcat.options), "hashcat-option", "extra `argument` of the hashcat command line for -format hashcat-sh and hashcat-json (repeatable, not split)")
+	var ipv4, ipv6 bool
+	fs.BoolVar(&ipv4, "4", false, "keep only the IPv4 targets (such as the IPv4 prefixes of -import)")
+	fs.BoolVar(&ipv6, "6", false, "keep only the IPv6 targets (such as the IPv6 prefixes of -import)")
+	var targetFiles listFlag
+	fs.Var(&targetFiles, "f", "read the targets from `file`, one per line, in any notation (- for stdin, repeatable)")
+	var hosts bool
+	var subnetBits int
+	fs.BoolVar(&hosts, "hosts", false, "exclude the network and broadcast addresses of each IPv4 subnet (see -subnet)")
+	fs.IntVar(&subnetBits, "subnet", 0, "prefix length of the subnets for -hosts (default: the prefix of a network target, required for the other targets)")
+	var public bool
+	fs.BoolVar(&public, "public", false, "keep only the globally reachable unicast IPv4 addresses (exclude @bogon)")
+	var excludes, excludeFiles listFlag
+	fs.Var(&excludes, "exclude", "exclude the addresses of the given networks, ranges or IPs (comma-separated, repeatable)")
+	fs.Var(&excludeFiles, "exclude-file", "exclude the addresses listed in the given file (one network, range or IP per line, # for comments)")
+	var imports, awsFiles listFlag
+	var filter cloud.Filter
+	fs.Var(&imports, "import", "import the prefixes of the published ranges `provider:file` of a cloud provider (repeatable)")
+	fs.Var(&awsFiles, "aws", "import the prefixes of an AWS `ip-ranges.json` file (same as -import aws:<file>)")
+	fs.Var((*listFlag)(&filter.Regions), "region", "select the imported prefixes by region (glob patterns, comma-separated, repeatable)")
+	fs.Var((*listFlag)(&filter.Services), "service", "select the imported prefixes by service (glob patterns, comma-separated, repeatable)")
+	fs.Var((*listFlag)(&filter.Tags), "tag", "select the imported prefixes by tag (glob patterns, comma-separated, repeatable)")
+	var rirFiles listFlag
+	var rirFilter rir.Filter
+	fs.Var(&rirFiles, "rir", "import the IPv4 blocks of an RIR delegated statistics `file` (repeatable)")
+	fs.Var((*listFlag)(&rirFilter.Countries), "country", "select the RIR blocks and the GeoIP networks by country code (comma-separated, repeatable)")
+	fs.Var((*listFlag)(&rirFilter.Registries), "registry", "select the RIR blocks by registry (comma-separated, repeatable)")
+	fs.Var((*listFlag)(&rirFilter.Statuses), "status", "select the RIR blocks by status: allocated, assigned, available, reserved (comma-separated, repeatable)")
+	var geoipFiles listFlag
+	var geoipFilter geoip.Filter
+	var geonames listFlag
+	fs.Var(&geoipFiles, "geoip", "import the IPv4 networks of a GeoIP2/GeoLite2 CSV database given as `blocks,locations` files (repeatable)")
+	fs.Var((*listFlag)(&geoipFilter.Continents), "continent", "select the GeoIP networks by continent code (comma-separated, repeatable)")
+	fs.Var(&geonames, "geoname", "select the GeoIP networks by geoname id (comma-separated, repeatable)")
+	var ip2asnFiles, asns listFlag
+	var asDescription string
+	fs.Var(&ip2asnFiles, "ip2asn", "import the IPv4 ranges of an ip2asn `tsv` file (repeatable)")
+	fs.Var(&asns, "asn", "select the ip2asn ranges by AS number (comma-separated, repeatable)")
+	fs.StringVar(&asDescription, "as-description", "", "select the ip2asn ranges by AS description (`regexp`)")
+	var nmapFiles, masscanFiles, ports listFlag
+	fs.Var(&nmapFiles, "nmap", "import the hosts that are up in an nmap XML output `file` (nmap -oX, repeatable)")
+	fs.Var(&masscanFiles, "masscan", "import the hosts of a masscan JSON output `file` (masscan -oJ, repeatable)")
+	fs.Var(&ports, "port", "select the scanned hosts by open port (comma-separated, repeatable)")
+	var iptablesFiles, ipsetFiles, nftFiles listFlag
+	var firewallFilter firewall.Filter
+	fs.Var(&iptablesFiles, "iptables", "import the source and destination networks of an iptables-save output `file` (repeatable)")
+	fs.Var(&ipsetFiles, "ipset", "import the members of the sets of an ipset save output `file` (repeatable)")
+	fs.Var(&nftFiles, "nft", "import the elements of the sets of an nft list ruleset output `file` (repeatable)")
+	fs.Var((*listFlag)(&firewallFilter.Names), "name", "select the firewall networks by iptables chain or by ipset/nftables set (comma-separated, repeatable)")
+	if err := fs.Parse(args); err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+
+	for _, file := range awsFiles {
+		imports = append(imports, "aws:"+file)
+	}
+
+	if fs.NArg() == 0 && len(targetFiles) == 0 && len(imports) == 0 && len(rirFiles) == 0 && len(geoipFiles) == 0 && len(ip2asnFiles) == 0 && len(nmapFiles) == 0 && len(masscanFiles) == 0 &&
+		len(iptablesFiles) == 0 && len(ipsetFiles) == 0 && len(nftFiles) == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	var targets []target
+	var invalid []invalidLine
+	// Targets are kept in the order of the arguments, with stdin ("-") read in place
+	for _, arg := range fs.Args() {
+		if arg == "-" {
+			t, err := readTargets(arg, &invalid)
+			if err != nil {
+				fmt.Fprintln(stdout, err)
+				return 1
+			}
+			targets = append(targets, t...)
+			continue
+		}
+		t, err := parseTarget(arg)
+		if err != nil {
+			printDiagnostic(stdout, "", err)
+			return 1
+		}
+		if t.label == "" && fs.NArg() > 1 {
+			t.label = arg
+		}
+		targets = append(targets, t)
+	}
+	for _, file := range targetFiles {
+		t, err := readTargets(file, &invalid)
+		if err != nil {
+			fmt.Fprintln(stdout, err)
+			return 1
+		}
+		targets = append(targets, t...)
+	}
+	for _, imp := range imports {
+		provider, file, found := strings.Cut(imp, ":")
+		if !found {
+			fmt.Fprintln(stdout, "import:", imp+": <provider>:<file> expected")
+			return 1
+		}
+		t, err := importCloud(provider, file, filter)
+		if err != nil {
+			fmt.Fprintln(stdout, err)
+			return 1
+		}
+		targets = append(targets, t...)
+	}
+
+	for _, file := range rirFiles {
+		t, err := importRIR(file, rirFilter)
+		if err != nil {
+			fmt.Fprintln(stdout, err)
+			return 1
+		}
+		targets = append(targets, t)
+	}
+
+	if len(geoipFiles)%2 != 0 {
+		fmt.Fprintln(stdout, "geoip: <blocks file>,<locations file> expected")
+		return 1
+	}
+	geoipFilter.Countries = rirFilter.Countries
+	for _, s := range geonames {
+		id, err := strconv.ParseUint(s, 10, 32)
+		if err != nil {
+			fmt.Fprintln(stdout, "geoname:", s+": invalid geoname id")
+			return 1
+		}
+		geoipFilter.GeonameIDs = append(geoipFilter.GeonameIDs, uint32(id))
+	}
+	for i := 0; i < len(geoipFiles); i += 2 {
+		t, err := importGeoIP(geoipFiles[i], geoipFiles[i+1], geoipFilter)
+		if err != nil {
+			fmt.Fprintln(stdout, err)
+			return 1
+		}
+		targets = append(targets, t)
+	}
+
+	var asnFilter ip2asn.Filter
+	for _, s := range asns {
+		asn, err := ip2asn.ParseASN(s)
+		if err != nil {
+			fmt.Fprintln(stdout, "asn:", err)
+			return 1
+		}
+		asnFilter.ASNs = append(asnFilter.ASNs, asn)
+	}
+	if asDescription != "" {
+		re, err := regexp.Compile(asDescription)
+		if err != nil {
+			fmt.Fprintln(stdout, "as-description:", err)
+			return 1
+		}
+		asnFilter.Description = re
+	}
+	for _, file := range ip2asnFiles {
+		t, err := importIP2ASN(file, asnFilter)
+		if err != nil {
+			fmt.Fprintln(stdout, err)
+			return 1
+		}
+		targets = append(targets, t)
+	}
+
+	var scanFilter scan.Filter
+	for _, s := range ports {
+		port, err := strconv.ParseUint(s, 10, 16)
+		if err != nil {
+			fmt.Fprintln(stdout, "port:", s+": invalid port")
+			return 1
+		}
+		scanFilter.Ports = append(scanFilter.Ports, uint16(port))
+	}
+	for _, file := range nmapFiles {
+		t, err := importScan(file, scan.ReadNmap, scanFilter)
+		if err != nil {
+			fmt.Fprintln(stdout, err)
+			return 1
+		}
+		targets = append(targets, t)
+	}
+	for _, file := range masscanFiles {
+		t, err := importScan(file, scan.ReadMasscan, scanFilter)
+		if err != nil {
+			fmt.Fprintln(stdout, err)
+			return 1
+		}
+		targets = append(targets, t)
+	}
+
+	for _, imp := range []struct {
+		files listFlag
+		read  func(io.Reader, firewall.Filter) ([]cidr2hcmask.IPv4Net, error)
+	}{
+		{iptablesFiles, firewall.ReadIPTables},
+		{ipsetFiles, firewall.ReadIPSet},
+		{nftFiles, firewall.ReadNftables},
+	} {
+		for _, file := range imp.files {
+			t, err := importFirewall(file, imp.read, firewallFilter)
+			if err != nil {
+				fmt.Fprintln(stdout, err)
+				return 1
+			}
+			targets = append(targets, t)
+		}
+	}
+
+	if ipv4 || ipv6 {
+		if ipv4 && ipv6 {
+			fmt.Fprintln(stdout, "-4 and -6 are mutually exclusive")
+			return 1
+		}
+		family := targets[:0]
+		for _, t := range targets {
+			if (t.set != nil) == ipv4 {
+				family = append(family, t)
+			}
+		}
+		if len(family) == 0 {
+			if ipv4 {
+				fmt.Fprintln(stdout, "no IPv4 target")
+			} else {
+				fmt.Fprintln(stdout, "no IPv6 target")
+			}
+			return 1
+		}
+		targets = family
+	}
+
+	var exclude cidr2hcmask.IPv4Set
+	for _, s := range excludes {
+		ex, err := parseTarget(s)
+		if err != nil {
+			fmt.Fprintln(stdout, "exclude:", err)
+			return 1
+		}
+		if ex.set == nil {
+			fmt.Fprintln(stdout, "exclude:", s+": IPv4 expected")
+			return 1
+		}
+		exclude = exclude.Union(ex.set())
+	}
+	for _, file := range excludeFiles {
+		ex, err := readTargetsFile(file)
+		if err != nil {
+			fmt.Fprintln(stdout, "exclude:", err)
+			return 1
+		}
+		exclude = exclude.Union(ex)
+	}
+	// The exclusions are summarized in the labels as they may be long lists
+	excludeDesc := "1 range"
+	if n := len(exclude.Ranges()); n != 1 {
+		excludeDesc = fmt.Sprintf("%d ranges", n)
+	}
+
+	// The IPv4 options would silently leave the IPv6 targets unchanged
+	for _, opt := range []struct {
+		name string
+		set  bool
+	}{
+		{"hosts", hosts},
+		{"exclude", !exclude.IsEmpty()},
+		{"public", public},
+	} {
+		if !opt.set {
+			continue
+		}
+		for _, t := range targets {
+			if t.set == nil {
+				fmt.Fprintln(stdout, opt.name+":", t.ipv6.String()+": IPv6 is not supported (use -4 to keep only the IPv4 targets)")
+				return 1
+			}
+		}
+	}
+
+	newFormat, ok := formats[format]
+	if !ok {
+		fmt.Fprintln(stdout, "format:", format+": unknown format")
+		return 1
+	}
+	outFormat := newFormat()
+	comments := outFormat.comments
+	if strings.HasPrefix(format, "hashcat-") && hashcat.hashFile == "" {
+		fmt.Fprintln(stdout, "format:", format+": -hash-file is required")
+		return 1
+	}
+
+	// IPv6 targets (the cloud imports may have some) are skipped before anything
+	// is written
+	if outFormat.ipv4Only {
+		ipv4Targets := targets[:0]
+		for _, t := range targets {
+			if t.set != nil {
+				ipv4Targets = append(ipv4Targets, t)
+			}
+		}
+		if skipped := len(targets) - len(ipv4Targets); skipped > 0 {
+			if len(ipv4Targets) == 0 {
+				fmt.Fprintln(stdout, "format:", format+": IPv6 is not supported")
+				return 1
+			}
+			fmt.Fprintf(stderr, "warning: format %s: IPv6 is not supported, %d IPv6 targets skipped\n", format, skipped)
+		}
+		targets = ipv4Targets
+	}
+
+	if outFormat.begin != nil {
+		if err := outFormat.begin(stdout); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+
+	for _, t := range targets {
+		if hosts {
+			var err error
+			if t, err = t.hosts(subnetBits); err != nil {
+				fmt.Fprintln(stdout, "hosts:", err)
+				return 1
+			}
+		}
+		if !exclude.IsEmpty() {
+			t = t.exclude(exclude, excludeDesc)
+		}
+		if public {
+			t = t.public()
+		}
+		if t.label != "" && comments {
+			fmt.Fprintln(stdout, "#", t.label)
+		}
+		if err := outFormat.write(t, stdout); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+
+	if outFormat.end != nil {
+		if err := outFormat.end(stdout); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+
+	if len(invalid) > 0 {
+		// The summary goes to stderr if it would corrupt the output
+		out := stdout
+		if !comments {
+			out = stderr
+		}
+		fmt.Fprintf(out, "# %d invalid lines:\n", len(invalid))
+		for _, l := range invalid {
+			var perr *cidr2hcmask.ParseError
+			if errors.As(l.err, &perr) {
+				fmt.Fprint(out, "#   ", l.pos, ": ")
+				printDiagnostic(out, "#   ", l.err)
+			} else if strings.HasPrefix(l.err.Error(), l.line) {
+				fmt.Fprintf(out, "#   %s: %v\n", l.pos, l.err)
+			} else {
+				fmt.Fprintf(out, "#   %s: %s: %v\n", l.pos, l.line, l.err)
+			}
+		}
+		return 1
+	}
+	return 0
+}
+
+// target is a block of addresses given on the command line.
+type target struct {
+	label string                     // empty for a single address
+	set   func() cidr2hcmask.IPv4Set // nil for IPv6
+	ipv6  cidr2hcmask.IPv6Net        // for IPv6
+	net   *cidr2hcmask.IPv4Net       // for a network or a single IP
+	write func(w io.Writer) error
+
+	sources []source // the blocks of the input, for IPv4
+}
+
+// source is a block of addresses as given in the input of a target.
+type source struct {
+	first, last uint32
+	text        string
+}
+
+// netSources returns the sources of the networks nets.
+func netSources(nets ...cidr2hcmask.IPv4Net) []source {
+	sources := make([]source, len(nets))
+	for i, net := range nets {
+		first, last := net.First(), net.Last()
+		sources[i] = source{binary.BigEndian.Uint32(first[:]), binary.BigEndian.Uint32(last[:]), net.String()}
+	}
+	return sources
+}
+
+// rangeSources returns the sources of the ranges, described by text or, if text
+// is empty, by themselves.
+func rangeSources(text string, ranges ...cidr2hcmask.IPv4Range) []source {
+	sources := make([]source, len(ranges))
+	for i, r := range ranges {
+		s := source{binary.BigEndian.Uint32(r.First[:]), binary.BigEndian.Uint32(r.Last[:]), text}
+		if s.text == "" && r.First == r.Last {
+			s.text = netip.AddrFrom4(r.First).String()
+		} else if s.text == "" {
+			s.text = r.String()
+		}
+		sources[i] = s
+	}
+	return sources
+}
+
+// ipv4SetTarget returns a target for the addresses of set, given in the input as
+// sources.
+func ipv4SetTarget(label string, set cidr2hcmask.IPv4Set, sources []source) target {
+	return target{
+		label:   label,
+		sources: sources,
+		set:     func() cidr2hcmask.IPv4Set { return set },
+		write:   func(w io.Writer) error { return cidr2hcmask.Set2HCMaskWrite(set, w) },
 	}
-	net, err := cidr2hcmask.ParseCIDR(os.Args[1])
+}
+
+// ipv6Target returns a target for the addresses of net.
+func ipv6Target(net cidr2hcmask.IPv6Net) target {
+	var label string
+	if net.Bits < 128 {
+		label = net.String()
+	}
+	return target{
+		label: label,
+		ipv6:  net,
+		write: func(w io.Writer) error { return cidr2hcmask.IPv6CIDR2HCMaskWrite(net, w) },
+	}
+}
+
+// exclude returns the target without the addresses of exclude, described by desc
+// in the label.
+func (t target) exclude(exclude cidr2hcmask.IPv4Set, desc string) target {
+	label := t.label
+	if label != "" {
+		label += " excluding " + desc
+	}
+	return ipv4SetTarget(label, t.set().Difference(exclude), t.sources)
+}
+
+// hosts returns the target without the network and broadcast addresses of its
+// subnets of prefix length subnetBits. subnetBits 0 means the prefix length of
+// the network of the target, which the other targets don't have.
+func (t target) hosts(subnetBits int) (target, error) {
+	if subnetBits == 0 {
+		if t.net == nil {
+			name := t.label
+			if name == "" {
+				name = t.set().String()
+			}
+			return target{}, fmt.Errorf("%s: not a network, -subnet is required", name)
+		}
+		subnetBits = t.net.Bits
+	}
+	set, err := t.set().Hosts(subnetBits)
 	if err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+		return target{}, err
+	}
+	label := t.label
+	if label != "" {
+		label += " (hosts)"
+	}
+	return ipv4SetTarget(label, set, t.sources), nil
+}
+
+// public returns the target without the addresses that are not globally reachable.
+func (t target) public() target {
+	label := t.label
+	if label != "" {
+		label += " (public)"
+	}
+	return ipv4SetTarget(label, t.set().Public(), t.sources)
+}
+
+// parseTarget parses presets, an IPv6 network, or an IPv4 network (see
+// parseIPv4Net), a range, an nmap-style target or a single IP.
+func parseTarget(s string) (target, error) {
+	if strings.HasPrefix(s, "@") {
+		set, err := cidr2hcmask.ParsePresets(s)
+		if err != nil {
+			return target{}, err
+		}
+		return ipv4SetTarget(s, set, rangeSources(s, set.Ranges()...)), nil
+	}
+	if strings.Contains(s, ":") {
+		net, err := cidr2hcmask.ParseIPv6CIDR(s)
+		if err != nil {
+			return target{}, err
+		}
+		return ipv6Target(net), nil
 	}
+	if strings.Count(s, ".") == 3 && strings.ContainsAny(s, "*,-") {
+		o, err := cidr2hcmask.ParseOctetRanges(s)
+		if err != nil {
+			return target{}, err
+		}
+		return target{
+			label:   o.String(),
+			set:     o.Set,
+			sources: rangeSources(o.String(), o.Set().Ranges()...),
+			write:   func(w io.Writer) error { return cidr2hcmask.OctetRanges2HCMaskWrite(o, w) },
+		}, nil
+	}
+	if strings.Contains(s, "-") {
+		r, err := cidr2hcmask.ParseRange(s)
+		if err != nil {
+			return target{}, err
+		}
+		var label string
+		if r.First != r.Last {
+			label = r.String()
+		}
+		return target{
+			label:   label,
+			set:     func() cidr2hcmask.IPv4Set { return cidr2hcmask.NewIPv4Set(r) },
+			write:   func(w io.Writer) error { return cidr2hcmask.Range2HCMaskWrite(r, w) },
+			sources: rangeSources("", r),
+		}, nil
+	}
+	net, err := parseIPv4Net(s)
+	if err != nil {
+		return target{}, err
+	}
+	var label string
 	if net.Bits < 32 {
-		fmt.Println("#", net)
+		label = net.String()
+	}
+	return target{
+		label:   label,
+		set:     func() cidr2hcmask.IPv4Set { return cidr2hcmask.NewIPv4SetFromNets(net) },
+		net:     &net,
+		write:   func(w io.Writer) error { return cidr2hcmask.CIDR2HCMaskWrite(net, w) },
+		sources: netSources(net),
+	}, nil
+}
+
+// parseIPv4Net parses a network in CIDR notation, or with a dotted netmask or a
+// wildcard mask, or a single IP. With -lenient, see [cidr2hcmask.ParseCIDRLenient].
+func parseIPv4Net(s string) (cidr2hcmask.IPv4Net, error) {
+	if lenient {
+		net, warning, err := cidr2hcmask.ParseCIDRLenient(s)
+		if warning != nil {
+			fmt.Fprintln(stderr, "warning:", warning)
+		}
+		return net, err
+	}
+	if _, mask, found := strings.Cut(s, "/"); strings.Contains(s, " ") || (found && strings.Contains(mask, ".")) {
+		return cidr2hcmask.ParseNetmask(s)
+	} else if !found {
+		net, err := cidr2hcmask.ParseCIDR(s + "/32")
+		// Report the error on the input, not on the network parsed
+		var perr *cidr2hcmask.ParseError
+		if errors.As(err, &perr) {
+			perr.Input = s
+			if perr.Offset > len(s) {
+				perr.Offset = len(s)
+			}
+			perr.Fix = strings.TrimSuffix(perr.Fix, "/32")
+		}
+		return net, err
+	}
+	return cidr2hcmask.ParseCIDR(s)
+}
+
+// scanTargetsFile calls fn with each line of a file ("-" for stdin), with blank
+// lines and comments starting with # removed.
+func scanTargetsFile(name string, fn func(lineNum int, line string) error) error {
+	r := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		if err := fn(lineNum, line); err != nil {
+			return fmt.Errorf("%s:%d: %w", name, lineNum, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// readTargetsFile reads the set of addresses listed in a file, one target per line.
+// Blank lines and comments starting with # are ignored.
+func readTargetsFile(name string) (cidr2hcmask.IPv4Set, error) {
+	var ranges []cidr2hcmask.IPv4Range
+	err := scanTargetsFile(name, func(_ int, line string) error {
+		t, err := parseTarget(line)
+		if err != nil {
+			return err
+		}
+		if t.set == nil {
+			return errors.New("IPv4 expected")
+		}
+		ranges = append(ranges, t.set().Ranges()...)
+		return nil
+	})
+	if err != nil {
+		return cidr2hcmask.IPv4Set{}, err
+	}
+	return cidr2hcmask.NewIPv4Set(ranges...), nil
+}
+
+// printDiagnostic prints err to w, followed for a [cidr2hcmask.ParseError] by the
+// input with a caret under the faulty part. indent prefixes the lines after the
+// first one.
+func printDiagnostic(w io.Writer, indent string, err error) {
+	fmt.Fprintln(w, err)
+	var perr *cidr2hcmask.ParseError
+	if errors.As(err, &perr) {
+		fmt.Fprintln(w, indent+"    "+perr.Input)
+		fmt.Fprintln(w, indent+"    "+strings.Repeat(" ", perr.Offset)+"^")
+	}
+}
+
+// invalidLine is a line of a targets file that could not be parsed.
+type invalidLine struct {
+	pos  string // file:line
+	line string
+	err  error
+}
+
+// readTargets reads the targets listed in a file ("-" for stdin), one per line, in
+// any notation accepted on the command line. Blank lines and comments starting
+// with # are ignored. The invalid lines are appended to invalid.
+func readTargets(name string, invalid *[]invalidLine) ([]target, error) {
+	var targets []target
+	err := scanTargetsFile(name, func(lineNum int, line string) error {
+		t, err := parseTarget(line)
+		if err != nil {
+			*invalid = append(*invalid, invalidLine{pos: fmt.Sprintf("%s:%d", name, lineNum), line: line, err: err})
+			return nil
+		}
+		if t.label == "" {
+			t.label = line
+		}
+		targets = append(targets, t)
+		return nil
+	})
+	return targets, err
+}
+
+// importCloud imports the prefixes of the published ranges file of a cloud
+// provider: IPv4 prefixes are merged in a single target, IPv6 prefixes are
+// aggregated (see the -4 and -6 flags to select a family).
+func importCloud(provider string, name string, filter cloud.Filter) ([]target, error) {
+	imp := cloud.Lookup(provider)
+	if imp == nil {
+		return nil, fmt.Errorf("%s: unknown provider (expected one of: %s)", provider, strings.Join(cloud.Providers(), ", "))
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	p, err := imp.Import(f, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	var targets []target
+	if len(p.IPv4) > 0 {
+		targets = append(targets, ipv4SetTarget(fmt.Sprintf("%s %s: %d IPv4 prefixes", provider, name, len(p.IPv4)), p.IPv4Set(), netSources(p.IPv4...)))
+	}
+	for _, net := range p.AggregateIPv6() {
+		targets = append(targets, ipv6Target(net))
+	}
+	return targets, nil
+}
+
+// importRIR imports the blocks of an RIR delegated statistics file.
+func importRIR(name string, filter rir.Filter) (target, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return target{}, err
+	}
+	defer f.Close()
+	ranges, err := rir.Read(f, filter)
+	if err != nil {
+		return target{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return ipv4SetTarget(fmt.Sprintf("%s: %d blocks", name, len(ranges)), cidr2hcmask.NewIPv4Set(ranges...), rangeSources("", ranges...)), nil
+}
+
+// importGeoIP imports the networks of a GeoIP CSV database.
+func importGeoIP(blocksName, locationsName string, filter geoip.Filter) (target, error) {
+	locationsFile, err := os.Open(locationsName)
+	if err != nil {
+		return target{}, err
+	}
+	defer locationsFile.Close()
+	locs, err := geoip.ReadLocations(locationsFile)
+	if err != nil {
+		return target{}, fmt.Errorf("%s: %w", locationsName, err)
+	}
+
+	blocksFile, err := os.Open(blocksName)
+	if err != nil {
+		return target{}, err
+	}
+	defer blocksFile.Close()
+	nets, err := geoip.ReadBlocks(blocksFile, locs, filter)
+	if err != nil {
+		return target{}, fmt.Errorf("%s: %w", blocksName, err)
+	}
+	return ipv4SetTarget(fmt.Sprintf("%s: %d networks", blocksName, len(nets)), cidr2hcmask.NewIPv4SetFromNets(nets...), netSources(nets...)), nil
+}
+
+// importIP2ASN imports the ranges of an ip2asn table.
+func importIP2ASN(name string, filter ip2asn.Filter) (target, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return target{}, err
+	}
+	defer f.Close()
+	ranges, err := ip2asn.Read(f, filter)
+	if err != nil {
+		return target{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return ipv4SetTarget(fmt.Sprintf("%s: %d ranges", name, len(ranges)), cidr2hcmask.NewIPv4Set(ranges...), rangeSources("", ranges...)), nil
+}
+
+// importScan imports the hosts found by a scan.
+func importScan(name string, read func(io.Reader, scan.Filter) (cidr2hcmask.IPv4Set, error), filter scan.Filter) (target, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return target{}, err
+	}
+	defer f.Close()
+	set, err := read(f, filter)
+	if err != nil {
+		return target{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return ipv4SetTarget(fmt.Sprintf("%s: %d hosts", name, set.Size()), set, rangeSources("", set.Ranges()...)), nil
+}
+
+// importFirewall imports the networks of a firewall ruleset.
+func importFirewall(name string, read func(io.Reader, firewall.Filter) ([]cidr2hcmask.IPv4Net, error), filter firewall.Filter) (target, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return target{}, err
+	}
+	defer f.Close()
+	nets, err := read(f, filter)
+	if err != nil {
+		return target{}, fmt.Errorf("%s: %w", name, err)
+	}
+	return ipv4SetTarget(fmt.Sprintf("%s: %d networks", name, len(nets)), cidr2hcmask.NewIPv4SetFromNets(nets...), netSources(nets...)), nil
+}
+
+// writeNDJSON writes the hashcat masks of a target as JSON objects, one per line.
+// See [cidr2hcmask.HCMaskInfo]. The source of a mask is the list of the blocks
+// of the input of the target that overlap the range of its candidates.
+func writeNDJSON(t target, w io.Writer) error {
+	index := newSourceIndex(t.sources)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return forEachMask(t, func(mask string) error {
+		info, err := cidr2hcmask.ParseHCMask(mask)
+		if err != nil {
+			return err
+		}
+		info.Label = t.label
+		if t.set == nil {
+			info.Source = []string{t.ipv6.String()}
+		} else if info.Source, err = index.overlap(info.First, info.Last); err != nil {
+			return err
+		}
+		return enc.Encode(info)
+	})
+}
+
+// sourceIndex finds the sources that overlap a range.
+type sourceIndex struct {
+	sources []source // sorted by first
+	maxLast []uint32 // maxLast[i] is the highest last of sources[:i+1]
+}
+
+func newSourceIndex(sources []source) sourceIndex {
+	sources = append([]source(nil), sources...)
+	sort.SliceStable(sources, func(i, j int) bool { return sources[i].first < sources[j].first })
+	maxLast := make([]uint32, len(sources))
+	for i, s := range sources {
+		maxLast[i] = s.last
+		if i > 0 && maxLast[i-1] > s.last {
+			maxLast[i] = maxLast[i-1]
+		}
+	}
+	return sourceIndex{sources, maxLast}
+}
+
+// overlap returns the text of the sources that overlap the range first-last, in
+// the order of their first address, without duplicates.
+func (x sourceIndex) overlap(first, last string) ([]string, error) {
+	r, err := cidr2hcmask.ParseRange(first + "-" + last)
+	if err != nil {
+		return nil, err
+	}
+	lo, hi := binary.BigEndian.Uint32(r.First[:]), binary.BigEndian.Uint32(r.Last[:])
+	// The sources before i start before hi, and the scan stops at the first one
+	// before which all the sources end before lo
+	i := sort.Search(len(x.sources), func(i int) bool { return x.sources[i].first > hi })
+	var found []source
+	for i--; i >= 0 && x.maxLast[i] >= lo; i-- {
+		if x.sources[i].last >= lo {
+			found = append(found, x.sources[i])
+		}
+	}
+	var texts []string
+	seen := make(map[string]bool, len(found))
+	for i := len(found) - 1; i >= 0; i-- {
+		if text := found[i].text; !seen[text] {
+			seen[text] = true
+			texts = append(texts, text)
+		}
+	}
+	return texts, nil
+}
+
+// hashcatCommand is the hashcat command line of the hashcat-sh and hashcat-json
+// formats.
+type hashcatCommand struct {
+	mode     string
+	hashFile string
+	options  []string
+}
+
+var hashcat hashcatCommand
+
+// argv returns the hashcat command line for a mask attack with mask. The unused
+// charsets of mask are removed, so the custom charsets options are only the ones
+// that the pattern uses.
+func (c *hashcatCommand) argv(mask string) ([]string, error) {
+	args, err := cidr2hcmask.HashcatArgs(cidr2hcmask.CompactMask(mask))
+	if err != nil {
+		return nil, err
+	}
+	argv := []string{"hashcat", "-a", "3"}
+	if c.mode != "" {
+		argv = append(argv, "-m", c.mode)
+	}
+	argv = append(argv, c.options...)
+	argv = append(argv, args[:len(args)-1]...) // charsets
+	return append(argv, c.hashFile, args[len(args)-1]), nil
+}
+
+// forEachMask calls fn with each hashcat mask of a target.
+func forEachMask(t target, fn func(mask string) error) error {
+	var masks bytes.Buffer
+	if err := t.write(&masks); err != nil {
+		return err
+	}
+	for _, mask := range strings.SplitAfter(masks.String(), "\n") {
+		if mask = strings.TrimSuffix(mask, "\n"); mask == "" {
+			continue
+		}
+		if err := fn(mask); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHashcatScript writes a hashcat command for each hashcat mask of a target.
+func writeHashcatScript(t target, w io.Writer) error {
+	return forEachMask(t, func(mask string) error {
+		argv, err := hashcat.argv(mask)
+		if err != nil {
+			return err
+		}
+		for i, arg := range argv {
+			argv[i] = cidr2hcmask.ShellQuote(arg)
+		}
+		_, err = io.WriteString(w, strings.Join(argv, " ")+"\n")
+		return err
+	})
+}
+
+// hashcatJSONWriter returns the write function of the hashcat-json format: it
+// writes the hashcat argv list of each hashcat mask of a target, as items of a
+// JSON array.
+func hashcatJSONWriter() func(t target, w io.Writer) error {
+	count := 0 // argv lists written
+	return func(t target, w io.Writer) error {
+		return forEachMask(t, func(mask string) error {
+			argv, err := hashcat.argv(mask)
+			if err != nil {
+				return err
+			}
+			b, err := json.Marshal(argv)
+			if err != nil {
+				return err
+			}
+			sep := ",\n"
+			if count == 0 {
+				sep = "\n"
+			}
+			count++
+			_, err = io.WriteString(w, sep+string(b))
+			return err
+		})
 	}
-	cidr2hcmask.CIDR2HCMaskWrite(net, os.Stdout)
 }
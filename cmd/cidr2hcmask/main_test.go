@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func mustParseTarget(t *testing.T, s string) target {
+	t.Helper()
+	tg, err := parseTarget(s)
+	if err != nil {
+		t.Fatalf("%s: %v", s, err)
+	}
+	return tg
+}
+
+// ndjsonSources returns the source of each mask written by writeNDJSON for tg.
+func ndjsonSources(t *testing.T, tg target) map[string]string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := writeNDJSON(tg, &buf); err != nil {
+		t.Fatal(err)
+	}
+	sources := make(map[string]string)
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var info cidr2hcmask.HCMaskInfo
+		if err := dec.Decode(&info); err != nil {
+			t.Fatal(err)
+		}
+		sources[info.First+"-"+info.Last] = fmt.Sprint(info.Source)
+	}
+	return sources
+}
+
+func TestNDJSONSource(t *testing.T) {
+	// The range is reported as given, not as the networks that cover it
+	tg := mustParseTarget(t, "10.0.0.200-10.0.1.10")
+	for r, source := range ndjsonSources(t, tg) {
+		if source != "[10.0.0.200-10.0.1.10]" {
+			t.Errorf("%s: got %s", r, source)
+		}
+	}
+	if hosts, err := tg.hosts(24); err != nil {
+		t.Error(err)
+	} else {
+		for r, source := range ndjsonSources(t, hosts) {
+			if source != "[10.0.0.200-10.0.1.10]" {
+				t.Errorf("hosts: %s: got %s", r, source)
+			}
+		}
+	}
+
+	// Overlapping input networks
+	nets := []cidr2hcmask.IPv4Net{
+		*mustParseTarget(t, "10.0.0.0/24").net,
+		*mustParseTarget(t, "10.0.0.0/25").net,
+		*mustParseTarget(t, "10.0.2.0/25").net,
+	}
+	tg = ipv4SetTarget("overlap", cidr2hcmask.NewIPv4SetFromNets(nets...), netSources(nets...))
+	expected := map[string]string{
+		"10.0.0.0-10.0.0.9":     "[10.0.0.0/24 10.0.0.0/25]",
+		"10.0.0.200-10.0.0.249": "[10.0.0.0/24]",
+		"10.0.2.0-10.0.2.9":     "[10.0.2.0/25]",
+	}
+	sources := ndjsonSources(t, tg)
+	for r, source := range expected {
+		if sources[r] != source {
+			t.Errorf("%s: got %s, expected %s", r, sources[r], source)
+		}
+	}
+}
+
+func TestHashcatArgv(t *testing.T) {
+	c := hashcatCommand{mode: "0", hashFile: "hashes.txt", options: []string{"-O"}}
+	for _, tc := range []struct {
+		mask     string
+		expected string
+	}{
+		{"01234,012345,123456789,10.0.0.25?2", "[hashcat -a 3 -m 0 -O -1 012345 hashes.txt 10.0.0.25?1]"},
+		{"01234,012345,123456789,10.0.0.1?d?d", "[hashcat -a 3 -m 0 -O hashes.txt 10.0.0.1?d?d]"},
+		{"01234,012345,123456789,1?3.?2.0.?d", "[hashcat -a 3 -m 0 -O -1 012345 -2 123456789 hashes.txt 1?2.?1.0.?d]"},
+	} {
+		argv, err := c.argv(tc.mask)
+		if err != nil {
+			t.Errorf("%s: %v", tc.mask, err)
+		} else if got := fmt.Sprint(argv); got != tc.expected {
+			t.Errorf("%s: got %s, expected %s", tc.mask, got, tc.expected)
+		}
+	}
+}
+
+const awsIPRanges = `{
+  "prefixes": [
+    {"ip_prefix": "10.0.0.0/30", "region": "eu-central-1", "service": "EC2"},
+    {"ip_prefix": "10.0.0.0/31", "region": "eu-central-1", "service": "AMAZON"},
+    {"ip_prefix": "10.0.1.0/30", "region": "us-east-1", "service": "EC2"}
+  ],
+  "ipv6_prefixes": [
+    {"ipv6_prefix": "2001:db8::/126", "region": "eu-central-1", "service": "EC2"}
+  ]
+}`
+
+const ipsetSave = `create allow hash:net family inet hashsize 1024 maxelem 65536
+add allow 10.0.2.0/30
+add allow 10.0.2.1 nomatch
+create ports bitmap:port range 0-1024
+add ports 80
+`
+
+// runCommand runs the command with args and the standard input in, in a directory
+// with the files awsIPRanges (aws.json) and ipsetSave (ipset.txt).
+func runCommand(t *testing.T, args []string, in string) (code int, out, errOut string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{"aws.json": awsIPRanges, "ipset.txt": ipsetSave} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	args = append([]string(nil), args...)
+	for i, arg := range args {
+		args[i] = strings.ReplaceAll(arg, "{dir}", dir)
+	}
+
+	var outBuf, errBuf bytes.Buffer
+	defer func(in io.Reader, out, errOut io.Writer) {
+		stdin, stdout, stderr = in, out, errOut
+	}(stdin, stdout, stderr)
+	stdin, stdout, stderr = strings.NewReader(in), &outBuf, &errBuf
+	code = run(args)
+	return code, outBuf.String(), errBuf.String()
+}
+
+func TestRun(t *testing.T) {
+	for _, tc := range []struct {
+		args     string // split on spaces, {dir} is the directory of the files
+		stdin    string
+		code     int
+		out      string // exact output, if not empty
+		contains string // in the output and the errors
+	}{
+		// Parse
+		{args: "10.0.0.0/30 10.0.0.9",
+			out: "# 10.0.0.0/30\n01234,012345,123456789,0123,10.0.0.?4\n# 10.0.0.9\n01234,012345,123456789,10.0.0.9\n"},
+		{args: "-format enumerate 10.0.0.0/30", out: "10.0.0.0\n10.0.0.1\n10.0.0.2\n10.0.0.3\n"},
+		{args: "-format enumerate 10.0.0.5 - 10.0.0.3", stdin: "10.0.0.1\n", out: "10.0.0.5\n10.0.0.1\n10.0.0.3\n"},
+		{args: "-format enumerate -f -", stdin: "10.0.0.256\n10.0.0.1\n", code: 1, out: "10.0.0.1\n",
+			contains: "# 1 invalid lines:\n#   -:1: 10.0.0.256: syntax error (octet > 255)"},
+		{args: "10.0.0.256", code: 1, contains: "syntax error"},
+		{args: "-lenient -format enumerate 10.0.0.1/30", out: "10.0.0.0\n10.0.0.1\n10.0.0.2\n10.0.0.3\n", contains: "warning:"},
+		{args: "", code: 1, contains: "usage:"},
+		{args: "-format unknown 10.0.0.1", code: 1, contains: "unknown format"},
+
+		// Import
+		{args: "-format enumerate -4 -aws {dir}/aws.json -region eu-*", out: "10.0.0.0\n10.0.0.1\n10.0.0.2\n10.0.0.3\n"},
+		{args: "-6 -import aws:{dir}/aws.json -service EC2 -region eu-central-1", contains: "# 2001:db8::/126\n"},
+		{args: "-aws {dir}/aws.json -tag x", code: 1, contains: "unsupported filter"},
+		{args: "-format enumerate -ipset {dir}/ipset.txt", out: "10.0.2.0\n10.0.2.2\n10.0.2.3\n"},
+		{args: "-format enumerate -import unknown:{dir}/aws.json", code: 1, contains: "unknown provider"},
+
+		// Exclude
+		{args: "-format enumerate -exclude 10.0.0.1,10.0.0.2 10.0.0.0/30", out: "10.0.0.0\n10.0.0.3\n"},
+		{args: "-exclude 10.0.0.0/31 10.0.0.0/30", contains: "# 10.0.0.0/30 excluding 1 range\n"},
+		{args: "-exclude 2001:db8::/64 10.0.0.0/30", code: 1, contains: "IPv4 expected"},
+
+		// Hosts
+		{args: "-format enumerate -hosts 10.0.0.0/29", out: "10.0.0.1\n10.0.0.2\n10.0.0.3\n10.0.0.4\n10.0.0.5\n10.0.0.6\n"},
+		{args: "-format enumerate -hosts -subnet 30 10.0.0.0/29", out: "10.0.0.1\n10.0.0.2\n10.0.0.5\n10.0.0.6\n"},
+		{args: "-format enumerate -hosts 10.0.0.0/31 10.0.0.9", out: "10.0.0.0\n10.0.0.1\n10.0.0.9\n"},
+		{args: "-hosts 10.0.0.200-10.0.1.10", code: 1, contains: "-subnet is required"},
+		{args: "-hosts -subnet 30 0.0.0.0/8", code: 1, contains: "too many subnets"},
+
+		// Public
+		{args: "-format enumerate -public 10.0.0.0/30 8.8.8.8", out: "8.8.8.8\n"},
+		{args: "-public 10.0.0.0/30 2001:db8::/126", code: 1, contains: "use -4"},
+		{args: "-hosts -aws {dir}/aws.json -subnet 24", code: 1, contains: "IPv6 is not supported"},
+
+		// Output
+		{args: "-format regex 10.0.0.0/30", out: "^10\\.0\\.0\\.[0-3]\\z\n"},
+		{args: "-format regex 10.0.0.0/30 2001:db8::/126", out: "^10\\.0\\.0\\.[0-3]\\z\n", contains: "1 IPv6 targets skipped"},
+		{args: "-format regex 2001:db8::/126", code: 1, contains: "IPv6 is not supported"},
+		{args: "-format ndjson 10.0.0.0-10.0.0.9", contains: `"source":["10.0.0.0-10.0.0.9"]`},
+		{args: "-format hashcat-sh -hash-file h -hash-mode 0 10.0.0.0/30",
+			out: "#!/bin/sh\n# 10.0.0.0/30\nhashcat -a 3 -m 0 -1 0123 h '10.0.0.?1'\n"},
+		{args: "-format hashcat-json -hash-file h 10.0.0.0/30 10.0.0.9",
+			out: "[\n[\"hashcat\",\"-a\",\"3\",\"-1\",\"0123\",\"h\",\"10.0.0.?1\"],\n[\"hashcat\",\"-a\",\"3\",\"h\",\"10.0.0.9\"]\n]\n"},
+		{args: "-format hashcat-json 10.0.0.0/30", code: 1, contains: "-hash-file is required"},
+	} {
+		code, out, errOut := runCommand(t, strings.Fields(tc.args), tc.stdin)
+		if code != tc.code {
+			t.Errorf("%s: exit code %d, expected %d\n%s%s", tc.args, code, tc.code, out, errOut)
+		}
+		if tc.out != "" && out != tc.out {
+			t.Errorf("%s: got:\n%s\nexpected:\n%s", tc.args, out, tc.out)
+		}
+		if !strings.Contains(out+errOut, tc.contains) {
+			t.Errorf("%s: %q expected in:\n%s%s", tc.args, tc.contains, out, errOut)
+		}
+	}
+}
+
+// TestRunHostsRange checks that -hosts with -subnet applies to the whole range,
+// not to the networks that cover it.
+func TestRunHostsRange(t *testing.T) {
+	code, out, errOut := runCommand(t, []string{"-format", "enumerate", "-hosts", "-subnet", "24", "10.0.0.200-10.0.1.10"}, "")
+	if code != 0 {
+		t.Fatalf("exit code %d: %s", code, errOut)
+	}
+	if n := strings.Count(out, "\n"); n != 65 {
+		t.Errorf("got %d addresses, expected 65", n)
+	}
+	if strings.Contains(out, "10.0.0.255\n") || strings.Contains(out, "10.0.1.0\n") {
+		t.Error("broadcast or network address found")
+	}
+}
@@ -0,0 +1,235 @@
+// Package firewall extracts the IPv4 networks of Linux firewall rulesets: the
+// addresses of the iptables-save rules, and the members of the ipset and nftables
+// sets.
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+// Filter selects the networks of a ruleset by the name of the iptables chain or
+// of the ipset/nftables set where they appear. With no Names, every network is
+// selected.
+type Filter struct {
+	Names []string
+}
+
+func (f Filter) match(name string) bool {
+	if len(f.Names) == 0 {
+		return true
+	}
+	for _, n := range f.Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseElement parses a network in CIDR notation, a single IP or a range
+// <first ip>-<last ip>, and appends its networks to nets. IPv6 elements are
+// ignored.
+func parseElement(nets []cidr2hcmask.IPv4Net, s string) ([]cidr2hcmask.IPv4Net, error) {
+	if strings.Contains(s, ":") {
+		return nets, nil
+	}
+	if strings.Contains(s, "-") {
+		r, err := cidr2hcmask.ParseRange(s)
+		if err == cidr2hcmask.ErrSyntax {
+			return nil, fmt.Errorf("%s: %w (invalid range)", s, err)
+		} else if err != nil {
+			return nil, err
+		}
+		return append(nets, cidr2hcmask.NewIPv4Set(r).Nets()...), nil
+	}
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil || !addr.Is4() {
+			return nil, fmt.Errorf("%s: %w (invalid IPv4 address)", s, cidr2hcmask.ErrSyntax)
+		}
+		return append(nets, cidr2hcmask.IPv4Net{IP: addr.As4(), Bits: 32}), nil
+	}
+	net, err := cidr2hcmask.ParseCIDR(s) // the error tells the input
+	if err != nil {
+		return nil, err
+	}
+	return append(nets, net), nil
+}
+
+// isIPv4SetType reports whether the members of an ipset of type typ (hash:net,
+// bitmap:port...) are addresses: the first dimension must be ip or net.
+func isIPv4SetType(typ string) bool {
+	_, dims, _ := strings.Cut(typ, ":")
+	first, _, _ := strings.Cut(dims, ",")
+	return first == "ip" || first == "net"
+}
+
+// isIPv4ElementType reports whether the elements of an nftables set declared with
+// "type ..." or "typeof ..." are IPv4 addresses (or start with one, for
+// concatenations).
+func isIPv4ElementType(decl string) bool {
+	kind, typ, _ := strings.Cut(decl, " ")
+	first, _, _ := strings.Cut(typ, " . ")
+	if kind == "typeof" {
+		return strings.HasPrefix(first, "ip ")
+	}
+	return first == "ipv4_addr"
+}
+
+// ReadIPTables imports the source (-s) and destination (-d) networks of the rules
+// of an iptables-save output, selected by chain name. Negated addresses
+// (! -s ...) are ignored.
+func ReadIPTables(r io.Reader, f Filter) ([]cidr2hcmask.IPv4Net, error) {
+	var nets []cidr2hcmask.IPv4Net
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		args := strings.Fields(scanner.Text())
+		if len(args) < 2 || (args[0] != "-A" && args[0] != "-I") || !f.match(args[1]) {
+			continue
+		}
+		for i := 2; i < len(args)-1; i++ {
+			switch args[i] {
+			case "-s", "--source", "-d", "--destination":
+			default:
+				continue
+			}
+			i++
+			if args[i] == "!" || args[i-2] == "!" { // "-s ! addr" (old format) or "! -s addr"
+				continue
+			}
+			var err error
+			for _, s := range strings.Split(args[i], ",") {
+				if nets, err = parseElement(nets, s); err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNum, err)
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nets, nil
+}
+
+// ReadIPSet imports the members of the sets of an ipset save output (hash:net,
+// hash:ip, bitmap:ip...), selected by set name. For sets with multiple
+// dimensions (hash:net,port...), only the first one is used. The sets whose
+// members are not IPv4 addresses (bitmap:port, hash:mac, list:set, family inet6)
+// are ignored.
+//
+// Entries flagged nomatch are exceptions, not members: their addresses are
+// removed from the members of their set.
+func ReadIPSet(r io.Reader, f Filter) ([]cidr2hcmask.IPv4Net, error) {
+	var names []string
+	members := make(map[string][]cidr2hcmask.IPv4Net)
+	nomatch := make(map[string][]cidr2hcmask.IPv4Net)
+	ignored := make(map[string]bool) // sets that don't hold IPv4 addresses
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		args := strings.Fields(scanner.Text())
+		if len(args) >= 3 && args[0] == "create" {
+			ignored[args[1]] = !isIPv4SetType(args[2])
+			for i := 3; i+1 < len(args); i++ {
+				if args[i] == "family" && args[i+1] == "inet6" {
+					ignored[args[1]] = true
+				}
+			}
+			continue
+		}
+		if len(args) < 3 || args[0] != "add" || !f.match(args[1]) || ignored[args[1]] {
+			continue
+		}
+		name := args[1]
+		if _, seen := members[name]; !seen {
+			names = append(names, name)
+			members[name] = nil
+		}
+		nets := members
+		for _, opt := range args[3:] {
+			if opt == "nomatch" {
+				nets = nomatch
+				break
+			}
+		}
+		member, _, _ := strings.Cut(args[2], ",")
+		var err error
+		if nets[name], err = parseElement(nets[name], member); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	var nets []cidr2hcmask.IPv4Net
+	for _, name := range names {
+		if len(nomatch[name]) == 0 {
+			nets = append(nets, members[name]...)
+			continue
+		}
+		set := cidr2hcmask.NewIPv4SetFromNets(members[name]...).Difference(cidr2hcmask.NewIPv4SetFromNets(nomatch[name]...))
+		nets = append(nets, set.Nets()...)
+	}
+	return nets, nil
+}
+
+// ReadNftables imports the elements of the named sets of an nft list ruleset
+// output, selected by set name. For elements with concatenations
+// (ipv4_addr . inet_service...), only the first part is used. The sets whose
+// elements are not IPv4 addresses (ipv6_addr, inet_service...) are ignored.
+func ReadNftables(r io.Reader, f Filter) ([]cidr2hcmask.IPv4Net, error) {
+	var nets []cidr2hcmask.IPv4Net
+	var set string   // name of the current set
+	var ignored bool // the current set doesn't hold IPv4 addresses
+	var elements bool
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if !elements {
+			if fields := strings.Fields(line); len(fields) == 3 && fields[0] == "set" && fields[2] == "{" {
+				set = fields[1]
+				ignored = false
+				continue
+			}
+			if line == "}" {
+				set = ""
+				continue
+			}
+			if strings.HasPrefix(line, "type ") || strings.HasPrefix(line, "typeof ") {
+				ignored = !isIPv4ElementType(line)
+				continue
+			}
+			if !strings.HasPrefix(line, "elements = {") || set == "" {
+				continue
+			}
+			line = strings.TrimSpace(line[len("elements = {"):])
+			elements = true
+		}
+		if strings.HasSuffix(line, "}") {
+			line = line[:len(line)-1]
+			elements = false
+		}
+		if !f.match(set) || ignored {
+			continue
+		}
+		for _, elem := range strings.Split(line, ",") {
+			fields := strings.Fields(elem)
+			if len(fields) == 0 {
+				continue
+			}
+			var err error
+			if nets, err = parseElement(nets, fields[0]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nets, nil
+}
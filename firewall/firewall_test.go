@@ -0,0 +1,174 @@
+package firewall_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask/firewall"
+)
+
+const iptablesSave = `# Generated by iptables-save v1.8.7 on Mon Nov 13 10:00:00 2023
+*filter
+:INPUT DROP [0:0]
+:FORWARD DROP [0:0]
+:OUTPUT ACCEPT [0:0]
+:SSH - [0:0]
+-A INPUT -i lo -j ACCEPT
+-A INPUT -s 10.0.0.0/8 -p tcp -m tcp --dport 22 -j SSH
+-A INPUT ! -s 192.168.0.0/16 -p tcp -m tcp --dport 80 -j DROP
+-A SSH -s 192.168.1.10/32,192.168.1.11/32 -j ACCEPT
+-A SSH -s 172.16.0.0/12 -d 172.16.1.1/32 -j ACCEPT
+-A FORWARD -d 203.0.113.0/24 -j ACCEPT
+COMMIT
+# Completed on Mon Nov 13 10:00:00 2023
+`
+
+func TestReadIPTables(t *testing.T) {
+	for _, tc := range []struct {
+		filter   firewall.Filter
+		expected string
+	}{
+		{firewall.Filter{}, "[10.0.0.0/8 192.168.1.10/32 192.168.1.11/32 172.16.0.0/12 172.16.1.1/32 203.0.113.0/24]"},
+		{firewall.Filter{Names: []string{"SSH"}}, "[192.168.1.10/32 192.168.1.11/32 172.16.0.0/12 172.16.1.1/32]"},
+		{firewall.Filter{Names: []string{"INPUT", "FORWARD"}}, "[10.0.0.0/8 203.0.113.0/24]"},
+		{firewall.Filter{Names: []string{"OUTPUT"}}, "[]"},
+	} {
+		nets, err := firewall.ReadIPTables(strings.NewReader(iptablesSave), tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(nets); got != tc.expected {
+			t.Errorf("%v: got %s, expected %s", tc.filter.Names, got, tc.expected)
+		}
+	}
+}
+
+const ipsetSave = `create allow hash:net family inet hashsize 1024 maxelem 65536
+add allow 10.1.0.0/16
+add allow 10.2.3.0/24
+add allow 10.1.128.0/17 nomatch
+create hosts hash:ip family inet hashsize 1024 maxelem 65536 timeout 3600
+add hosts 192.0.2.1 timeout 3000
+add hosts 192.0.2.7 timeout 3000
+create web hash:net,port family inet hashsize 1024 maxelem 65536
+add web 198.51.100.0/24,tcp:443
+create allow6 hash:net family inet6 hashsize 1024 maxelem 65536
+add allow6 2001:db8::/32
+create ports bitmap:port range 0-1024
+add ports 80
+add ports 443
+create macs hash:mac hashsize 1024 maxelem 65536
+add macs 00:11:22:33:44:55
+create all list:set size 8
+add all allow
+add all hosts
+`
+
+func TestReadIPSet(t *testing.T) {
+	for _, tc := range []struct {
+		filter   firewall.Filter
+		expected string
+	}{
+		{firewall.Filter{}, "[10.1.0.0/17 10.2.3.0/24 192.0.2.1/32 192.0.2.7/32 198.51.100.0/24]"},
+		{firewall.Filter{Names: []string{"allow"}}, "[10.1.0.0/17 10.2.3.0/24]"},
+		{firewall.Filter{Names: []string{"hosts"}}, "[192.0.2.1/32 192.0.2.7/32]"},
+		{firewall.Filter{Names: []string{"web", "allow6"}}, "[198.51.100.0/24]"},
+		{firewall.Filter{Names: []string{"ports", "macs", "all"}}, "[]"},
+	} {
+		nets, err := firewall.ReadIPSet(strings.NewReader(ipsetSave), tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(nets); got != tc.expected {
+			t.Errorf("%v: got %s, expected %s", tc.filter.Names, got, tc.expected)
+		}
+	}
+}
+
+const nftRuleset = `table inet filter {
+	set allowed {
+		type ipv4_addr
+		flags interval
+		elements = { 10.0.0.0/8, 192.168.1.1,
+			     192.168.2.0-192.168.2.5 }
+	}
+
+	set blocked {
+		type ipv4_addr
+		elements = { 198.51.100.7 timeout 1h expires 59m }
+	}
+
+	set allowed6 {
+		type ipv6_addr
+		flags interval
+		elements = { 2001:db8::/32 }
+	}
+
+	set services {
+		type ipv4_addr . inet_service
+		elements = { 203.0.113.1 . 443 }
+	}
+
+	set ports {
+		type inet_service
+		flags interval
+		elements = { 22, 80,
+			     8000-8080 }
+	}
+
+	set ifaces {
+		type ifname
+		elements = { "eth0", "lo" }
+	}
+
+	set admins {
+		typeof ip saddr
+		elements = { 192.0.2.10 }
+	}
+
+	set admins6 {
+		typeof ip6 saddr
+		elements = { 2001:db8::10 }
+	}
+
+	chain input {
+		type filter hook input priority filter; policy drop;
+		ip saddr @allowed accept
+		ip saddr { 172.16.0.1, 172.16.0.2 } accept
+	}
+}
+`
+
+func TestReadNftables(t *testing.T) {
+	for _, tc := range []struct {
+		filter   firewall.Filter
+		expected string
+	}{
+		{firewall.Filter{}, "[10.0.0.0/8 192.168.1.1/32 192.168.2.0/30 192.168.2.4/31 198.51.100.7/32 203.0.113.1/32 192.0.2.10/32]"},
+		{firewall.Filter{Names: []string{"ports", "ifaces", "admins6"}}, "[]"},
+		{firewall.Filter{Names: []string{"allowed"}}, "[10.0.0.0/8 192.168.1.1/32 192.168.2.0/30 192.168.2.4/31]"},
+		{firewall.Filter{Names: []string{"blocked"}}, "[198.51.100.7/32]"},
+		{firewall.Filter{Names: []string{"input"}}, "[]"},
+	} {
+		nets, err := firewall.ReadNftables(strings.NewReader(nftRuleset), tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(nets); got != tc.expected {
+			t.Errorf("%v: got %s, expected %s", tc.filter.Names, got, tc.expected)
+		}
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	if _, err := firewall.ReadIPTables(strings.NewReader("-A INPUT -s 10.0.0.1/8 -j ACCEPT\n"), firewall.Filter{}); err == nil {
+		t.Error("iptables: error expected")
+	}
+	if _, err := firewall.ReadIPSet(strings.NewReader("add allow 10.0.0.256\n"), firewall.Filter{}); err == nil {
+		t.Error("ipset: error expected")
+	}
+	if _, err := firewall.ReadNftables(strings.NewReader("set s {\nelements = { 10.0.0.5-10.0.0.1 }\n}\n"), firewall.Filter{}); err == nil {
+		t.Error("nftables: error expected")
+	}
+}
@@ -0,0 +1,163 @@
+// Package geoip reads the MaxMind GeoIP2/GeoLite2 CSV databases (Country or
+// City): the IPv4 networks of the blocks file, located by the geoname ids of the
+// locations file.
+//
+// Format: https://dev.maxmind.com/geoip/docs/databases/city-and-country#csv-databases
+package geoip
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/dolmen-go/cidr2hcmask"
+	"github.com/dolmen-go/cidr2hcmask/internal/match"
+)
+
+// Location is a row of a locations CSV file (GeoLite2-Country-Locations-en.csv).
+type Location struct {
+	GeonameID     uint32
+	ContinentCode string
+	CountryISO    string
+}
+
+// Locations are locations indexed by geoname id.
+type Locations map[uint32]Location
+
+// Filter selects the networks of a blocks file by location.
+//
+// Codes are matched without regard to case. A network is selected if its
+// location matches one of the values of each non-empty list.
+type Filter struct {
+	Countries  []string // ISO 3166 2-letter codes (ex: FR)
+	Continents []string // AF, AN, AS, EU, NA, OC, SA
+	GeonameIDs []uint32
+}
+
+func (f Filter) match(loc Location) bool {
+	return match.Fold(f.Countries, loc.CountryISO) &&
+		match.Fold(f.Continents, loc.ContinentCode) &&
+		(len(f.GeonameIDs) == 0 || containsID(f.GeonameIDs, loc.GeonameID))
+}
+
+func containsID(ids []uint32, id uint32) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+// csvReader returns a CSV reader for r and the index of the columns of the header.
+func csvReader(r io.Reader, columns ...string) (*csv.Reader, []int, error) {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	index := make([]int, len(columns))
+	for i, col := range columns {
+		index[i] = -1
+		for j, h := range header {
+			if h == col {
+				index[i] = j
+				break
+			}
+		}
+		if index[i] < 0 {
+			return nil, nil, fmt.Errorf("column %q not found", col)
+		}
+	}
+	return cr, index, nil
+}
+
+func parseGeonameID(s string) (uint32, error) {
+	n, err := strconv.ParseUint(s, 10, 32)
+	return uint32(n), err
+}
+
+// ReadLocations reads a locations CSV file (GeoLite2-Country-Locations-*.csv or
+// GeoLite2-City-Locations-*.csv).
+func ReadLocations(r io.Reader) (Locations, error) {
+	cr, cols, err := csvReader(r, "geoname_id", "continent_code", "country_iso_code")
+	if err != nil {
+		return nil, fmt.Errorf("locations: %w", err)
+	}
+	locs := make(Locations)
+	for {
+		record, err := cr.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return locs, nil
+			}
+			return nil, fmt.Errorf("locations: %w", err)
+		}
+		id, err := parseGeonameID(record[cols[0]])
+		if err != nil {
+			return nil, fmt.Errorf("locations: %q: invalid geoname_id", record[cols[0]])
+		}
+		locs[id] = Location{
+			GeonameID:     id,
+			ContinentCode: record[cols[1]],
+			CountryISO:    record[cols[2]],
+		}
+	}
+}
+
+// ReadBlocks reads an IPv4 blocks CSV file (GeoLite2-Country-Blocks-IPv4.csv or
+// GeoLite2-City-Blocks-IPv4.csv) and returns the networks whose location matches f.
+//
+// The location of a network is its geoname_id, or its registered country if the
+// geoname_id is empty.
+//
+// The networks are aggregated: adjacent networks are merged into the minimal list
+// of CIDR blocks.
+func ReadBlocks(r io.Reader, locs Locations, f Filter) ([]cidr2hcmask.IPv4Net, error) {
+	cr, cols, err := csvReader(r, "network", "geoname_id", "registered_country_geoname_id")
+	if err != nil {
+		return nil, fmt.Errorf("blocks: %w", err)
+	}
+	var set cidr2hcmask.IPv4Set
+	for {
+		record, err := cr.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return set.Nets(), nil
+			}
+			return nil, fmt.Errorf("blocks: %w", err)
+		}
+		idStr := record[cols[1]]
+		if idStr == "" {
+			idStr = record[cols[2]]
+		}
+		if idStr == "" {
+			continue
+		}
+		id, err := parseGeonameID(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("blocks: %q: invalid geoname_id", idStr)
+		}
+		loc, ok := locs[id]
+		if !ok || !f.match(loc) {
+			continue
+		}
+		net, err := cidr2hcmask.ParseCIDR(record[cols[0]])
+		if err != nil {
+			return nil, fmt.Errorf("blocks: %q: %w", record[cols[0]], err)
+		}
+		set.AddNet(net)
+	}
+}
+
+// Read joins a blocks CSV file with a locations CSV file. See [ReadBlocks].
+func Read(blocks io.Reader, locations io.Reader, f Filter) ([]cidr2hcmask.IPv4Net, error) {
+	locs, err := ReadLocations(locations)
+	if err != nil {
+		return nil, err
+	}
+	return ReadBlocks(blocks, locs, f)
+}
@@ -0,0 +1,63 @@
+package geoip_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask/geoip"
+)
+
+const countryLocations = `geoname_id,locale_code,continent_code,continent_name,country_iso_code,country_name,is_in_european_union
+3017382,en,EU,Europe,FR,France,1
+2921044,en,EU,Europe,DE,Germany,1
+6252001,en,NA,"North America",US,"United States",0
+6255148,en,EU,Europe,,,0
+`
+
+const countryBlocksIPv4 = `network,geoname_id,registered_country_geoname_id,represented_country_geoname_id,is_anonymous_proxy,is_satellite_provider,is_anycast
+2.0.0.0/16,3017382,3017382,,0,0,
+2.1.0.0/16,3017382,3017382,,0,0,
+2.2.0.0/15,3017382,3017382,,0,0,
+2.4.0.0/24,,3017382,,0,0,
+2.16.0.0/23,2921044,2921044,,0,0,
+2.16.2.0/24,6255148,6255148,,0,0,
+3.0.0.0/9,6252001,6252001,,0,0,
+5.0.0.0/24,,,,1,0,
+`
+
+func TestRead(t *testing.T) {
+	for _, tc := range []struct {
+		filter   geoip.Filter
+		expected string
+	}{
+		{geoip.Filter{Countries: []string{"fr"}}, "[2.0.0.0/14 2.4.0.0/24]"},
+		{geoip.Filter{Continents: []string{"EU"}}, "[2.0.0.0/14 2.4.0.0/24 2.16.0.0/23 2.16.2.0/24]"},
+		{geoip.Filter{Continents: []string{"EU"}, Countries: []string{"DE", "US"}}, "[2.16.0.0/23]"},
+		{geoip.Filter{GeonameIDs: []uint32{6252001, 6255148}}, "[2.16.2.0/24 3.0.0.0/9]"},
+		{geoip.Filter{Countries: []string{"IT"}}, "[]"},
+	} {
+		nets, err := geoip.Read(strings.NewReader(countryBlocksIPv4), strings.NewReader(countryLocations), tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := fmt.Sprint(nets); got != tc.expected {
+			t.Errorf("%+v: got %s, expected %s", tc.filter, got, tc.expected)
+		}
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, tc := range [][2]string{
+		{"network,geoname_id\n", countryLocations},
+		{countryBlocksIPv4, "geoname_id,country_iso_code\n"},
+		{countryBlocksIPv4 + "2.5.0.1/24,3017382,3017382,,0,0,\n", countryLocations},
+		{countryBlocksIPv4 + "2.5.0.0/24,x,3017382,,0,0,\n", countryLocations},
+	} {
+		if _, err := geoip.Read(strings.NewReader(tc[0]), strings.NewReader(tc[1]), geoip.Filter{}); err == nil {
+			t.Errorf("%q: error expected", tc[0])
+		} else {
+			t.Log(err)
+		}
+	}
+}
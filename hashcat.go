@@ -0,0 +1,45 @@
+package cidr2hcmask
+
+import (
+	"strconv"
+	"strings"
+)
+
+// HashcatArgs converts a line of a hashcat mask file to arguments of the hashcat
+// command line for a mask attack (-a 3): the custom charsets as options -1 to -4,
+// followed by the pattern as the last argument.
+//
+// Exemple: "01234,012345,123456789,192.168.1.1?2" gives
+// ["-1" "01234" "-2" "012345" "-3" "123456789" "192.168.1.1?2"].
+//
+// Errors returned (check with [errors.Is]): [ErrSyntax]
+func HashcatArgs(mask string) ([]string, error) {
+	info, err := ParseHCMask(mask)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, 2*len(info.Charsets)+1)
+	for i, cs := range info.Charsets {
+		args = append(args, "-"+strconv.Itoa(i+1), cs)
+	}
+	return append(args, info.Pattern), nil
+}
+
+// shellSafeChars are the characters that have no special meaning for a POSIX
+// shell, anywhere in a word.
+const shellSafeChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_-+=.,:/@%"
+
+// ShellQuote quotes s as a single word for a POSIX shell, to write a hashcat
+// command line in a script. s is returned unchanged if it has only characters
+// without special meaning, else it is enclosed in single quotes, with each single
+// quote of s written as:
+//
+//	'\''
+//
+// Exemple: "192.168.1.1?2" gives "'192.168.1.1?2'" (? is a glob character).
+func ShellQuote(s string) string {
+	if s != "" && strings.Trim(s, shellSafeChars) == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
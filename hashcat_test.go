@@ -0,0 +1,80 @@
+package cidr2hcmask_test
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func TestHashcatArgs(t *testing.T) {
+	for _, tc := range []struct {
+		mask     string
+		expected string
+	}{
+		{"01234,012345,123456789,192.168.1.1?2", `["-1" "01234" "-2" "012345" "-3" "123456789" "192.168.1.1?2"]`},
+		{"01234,012345,123456789,0123,10.?4.0.1", `["-1" "01234" "-2" "012345" "-3" "123456789" "-4" "0123" "10.?4.0.1"]`},
+		{"10.0.0.1", `["10.0.0.1"]`},
+	} {
+		args, err := cidr2hcmask.HashcatArgs(tc.mask)
+		if err != nil {
+			t.Errorf("%s: %v", tc.mask, err)
+			continue
+		}
+		if got := fmt.Sprintf("%q", args); got != tc.expected {
+			t.Errorf("%s: got %s, expected %s", tc.mask, got, tc.expected)
+		}
+	}
+	if _, err := cidr2hcmask.HashcatArgs("10.?4"); !errors.Is(err, cidr2hcmask.ErrSyntax) {
+		t.Errorf("ErrSyntax expected, got %v", err)
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+	}{
+		{"", `''`},
+		{"'", `''\'''`},
+		{"a b", `'a b'`},
+		{"192.168.1.1?2", `'192.168.1.1?2'`},
+		{"*", `'*'`},
+		{"$HOME", `'$HOME'`},
+		{"~root", `'~root'`},
+		{"it's", `'it'\''s'`},
+		{"a\nb", "'a\nb'"},
+		{"--session=a_1", "--session=a_1"},
+		{"/tmp/hashes.txt", "/tmp/hashes.txt"},
+		{"123456789abcdef", "123456789abcdef"},
+	}
+	for _, tc := range tests {
+		if got := cidr2hcmask.ShellQuote(tc.in); got != tc.expected {
+			t.Errorf("%q: got %s, expected %s", tc.in, got, tc.expected)
+		}
+	}
+
+	// Check that the shell gives back the original words
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not found")
+	}
+	var script strings.Builder
+	script.WriteString(`printf '%s\0'`)
+	for _, tc := range tests {
+		script.WriteString(" " + cidr2hcmask.ShellQuote(tc.in))
+	}
+	out, err := exec.Command(sh, "-c", script.String()).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := strings.Split(string(out), "\x00")
+	for i, tc := range tests {
+		if words[i] != tc.in {
+			t.Errorf("sh: got %q, expected %q", words[i], tc.in)
+		}
+	}
+}
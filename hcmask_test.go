@@ -7,11 +7,13 @@ func HCMaskExpand(mask string, visit func([]byte)) {
 	parts := strings.Split(mask, ",")
 	pattern := parts[len(parts)-1]
 
-	var charsets [5]string
+	var charsets [7]string
 	charsets[0] = "0123456789" // ?d
 	for i := 0; i < len(parts)-1; i++ {
 		charsets[i+1] = parts[i]
 	}
+	charsets[5] = "0123456789abcdef" // ?h
+	charsets[6] = "0123456789ABCDEF" // ?H
 	parts = nil
 
 	buf := make([]byte, 0, len(pattern))
@@ -21,9 +23,16 @@ func HCMaskExpand(mask string, visit func([]byte)) {
 		if pattern[i] == '?' {
 			i++
 			var charset int
-			if pattern[i] != 'd' {
+			switch pattern[i] {
+			case 'd':
+				charset = 0
+			case 'h':
+				charset = 5
+			case 'H':
+				charset = 6
+			default:
 				charset = int(pattern[i] - '0')
-			} // else charset = 0
+			}
 			vars = append(vars, hcmaskVar{Index: len(buf) - 1, Charset: charset})
 		}
 	}
@@ -38,7 +47,7 @@ func HCMaskExpand(mask string, visit func([]byte)) {
 
 type hcmask struct {
 	work     []byte
-	charsets [5]string
+	charsets [7]string
 	vars     []hcmaskVar
 }
 
@@ -0,0 +1,126 @@
+package cidr2hcmask
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// HCMaskInfo describes a line of a hashcat mask file.
+type HCMaskInfo struct {
+	HCMask   string   `json:"hcmask"`
+	Charsets []string `json:"charsets"` // custom charsets ?1 to ?4
+	Pattern  string   `json:"pattern"`
+	Label    string   `json:"label,omitempty"`  // free text, not set by ParseHCMask
+	Source   []string `json:"source,omitempty"` // input networks covered, not set by ParseHCMask
+	First    string   `json:"first"`            // first candidate
+	Last     string   `json:"last"`             // last candidate
+	Count    *big.Int `json:"count"`            // count of candidates
+}
+
+// hcmaskBuiltinCharsets are the built-in charsets of hashcat.
+var hcmaskBuiltinCharsets = map[byte]string{
+	'l': "abcdefghijklmnopqrstuvwxyz",
+	'u': "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	'd': "0123456789",
+	'h': "0123456789abcdef",
+	'H': "0123456789ABCDEF",
+	's': " !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~",
+}
+
+// hcmaskPositions returns the characters allowed at each position of a pattern,
+// in ascending order. charsets are the custom charsets, already expanded.
+func hcmaskPositions(pattern string, charsets []string) ([]string, error) {
+	var positions []string
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '?' {
+			positions = append(positions, pattern[i:i+1])
+			continue
+		}
+		if i++; i == len(pattern) {
+			return nil, fmt.Errorf("%q: %w (incomplete ?)", pattern, ErrSyntax)
+		}
+		switch c := pattern[i]; {
+		case c == '?':
+			positions = append(positions, "?")
+		case c == 'a':
+			positions = append(positions, sortedChars(hcmaskBuiltinCharsets['l']+hcmaskBuiltinCharsets['u']+hcmaskBuiltinCharsets['d']+hcmaskBuiltinCharsets['s']))
+		case c >= '1' && c <= '4':
+			if int(c-'1') >= len(charsets) {
+				return nil, fmt.Errorf("%q: %w (undefined charset ?%c)", pattern, ErrSyntax, c)
+			}
+			positions = append(positions, charsets[c-'1'])
+		default:
+			cs, ok := hcmaskBuiltinCharsets[c]
+			if !ok {
+				return nil, fmt.Errorf("%q: %w (unknown charset ?%c)", pattern, ErrSyntax, c)
+			}
+			positions = append(positions, sortedChars(cs))
+		}
+	}
+	return positions, nil
+}
+
+// sortedChars returns the distinct characters of s in ascending order.
+func sortedChars(s string) string {
+	var set [256]bool
+	for i := 0; i < len(s); i++ {
+		set[s[i]] = true
+	}
+	b := make([]byte, 0, len(s))
+	for c, ok := range set {
+		if ok {
+			b = append(b, byte(c))
+		}
+	}
+	return string(b)
+}
+
+// ParseHCMask parses a line of a hashcat mask file "[charset,]...pattern" with up to
+// 4 custom charsets, and computes the first and last candidates and the count of
+// candidates.
+//
+// Escaped commas (\,) are not supported.
+//
+// Errors returned (check with [errors.Is]): [ErrSyntax]
+func ParseHCMask(mask string) (HCMaskInfo, error) {
+	parts := strings.Split(mask, ",")
+	if len(parts) > 5 {
+		return HCMaskInfo{}, fmt.Errorf("%q: %w (more than 4 charsets)", mask, ErrSyntax)
+	}
+	info := HCMaskInfo{
+		HCMask:   mask,
+		Charsets: parts[:len(parts)-1],
+		Pattern:  parts[len(parts)-1],
+	}
+
+	// A custom charset may use the built-in charsets and the previous custom charsets
+	charsets := make([]string, 0, len(info.Charsets))
+	for _, cs := range info.Charsets {
+		positions, err := hcmaskPositions(cs, charsets)
+		if err != nil {
+			return HCMaskInfo{}, err
+		}
+		charsets = append(charsets, sortedChars(strings.Join(positions, "")))
+	}
+
+	positions, err := hcmaskPositions(info.Pattern, charsets)
+	if err != nil {
+		return HCMaskInfo{}, err
+	}
+	first := make([]byte, len(positions))
+	last := make([]byte, len(positions))
+	info.Count = big.NewInt(1)
+	var size big.Int
+	for i, chars := range positions {
+		if chars == "" {
+			return HCMaskInfo{}, fmt.Errorf("%q: %w (empty charset)", mask, ErrSyntax)
+		}
+		first[i] = chars[0]
+		last[i] = chars[len(chars)-1]
+		info.Count.Mul(info.Count, size.SetInt64(int64(len(chars))))
+	}
+	info.First = string(first)
+	info.Last = string(last)
+	return info, nil
+}
@@ -0,0 +1,61 @@
+package cidr2hcmask_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func TestParseHCMask(t *testing.T) {
+	for _, tc := range []struct {
+		mask, first, last, count string
+	}{
+		{"01234,012345,123456789,192.168.1.?d", "192.168.1.0", "192.168.1.9", "10"},
+		{"01234,012345,123456789,192.168.1.2?1?d", "192.168.1.200", "192.168.1.249", "50"},
+		{"01234,012345,123456789,0123,10.?4.?3?d.25?2", "10.0.10.250", "10.3.99.255", "2160"},
+		{"10.0.0.1", "10.0.0.1", "10.0.0.1", "1"},
+		{"123456789abcdef,2001:db8::?1?h?h?h:?h", "2001:db8::1000:0", "2001:db8::ffff:f", "983040"},
+		{"123456789abcdef,2001:?H?H?H?H:?H?H?H?H:?H?H?H?H:?H?H?H?H:?H?H?H?H", "2001:0000:0000:0000:0000:0000", "2001:FFFF:FFFF:FFFF:FFFF:FFFF", "1208925819614629174706176"},
+		{"?d,a?1,?2??", "0?", "a?", "11"},
+	} {
+		info, err := cidr2hcmask.ParseHCMask(tc.mask)
+		if err != nil {
+			t.Errorf("%s: %v", tc.mask, err)
+			continue
+		}
+		if info.HCMask != tc.mask || info.First != tc.first || info.Last != tc.last || info.Count.String() != tc.count {
+			t.Errorf("%s: got %+v", tc.mask, info)
+		}
+	}
+
+	info, err := cidr2hcmask.ParseHCMask("01234,012345,123456789,10.?3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Charsets) != 3 || info.Charsets[2] != "123456789" || info.Pattern != "10.?3" {
+		t.Errorf("got %+v", info)
+	}
+
+	for _, mask := range []string{"a,b,c,d,e,f", "10.?4", "10.?x", "10.?", ",?1"} {
+		if _, err := cidr2hcmask.ParseHCMask(mask); !errors.Is(err, cidr2hcmask.ErrSyntax) {
+			t.Errorf("%q: ErrSyntax expected, got %v", mask, err)
+		}
+	}
+}
+
+func TestParseHCMaskCount(t *testing.T) {
+	// The counts of the masks of a network add up to its size
+	net := mustParseCIDR("10.0.0.0/12")
+	var total uint64
+	cidr2hcmask.CIDR2HCMaskFunc(net, func(mask string) {
+		info, err := cidr2hcmask.ParseHCMask(mask)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += info.Count.Uint64()
+	})
+	if total != net.Size() {
+		t.Errorf("got %d, expected %d", total, net.Size())
+	}
+}
@@ -0,0 +1,37 @@
+// Package match implements the matching of the values of the records read by the
+// importers against the lists of values of their filters.
+package match
+
+import (
+	"path"
+	"strings"
+)
+
+// Fold reports whether value is equal to one of values, without regard to case.
+// An empty list matches any value.
+func Fold(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Glob reports whether value matches one of patterns (see [path.Match]), without
+// regard to case. An empty list matches any value.
+func Glob(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	value = strings.ToLower(value)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), value); ok {
+			return true
+		}
+	}
+	return false
+}
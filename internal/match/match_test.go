@@ -0,0 +1,43 @@
+package match_test
+
+import (
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask/internal/match"
+)
+
+func TestFold(t *testing.T) {
+	for _, tc := range []struct {
+		values   []string
+		value    string
+		expected bool
+	}{
+		{nil, "FR", true},
+		{[]string{"fr"}, "FR", true},
+		{[]string{"DE", "Fr"}, "fR", true},
+		{[]string{"DE"}, "FR", false},
+		{[]string{"F*"}, "FR", false},
+	} {
+		if got := match.Fold(tc.values, tc.value); got != tc.expected {
+			t.Errorf("%q %q: got %t", tc.values, tc.value, got)
+		}
+	}
+}
+
+func TestGlob(t *testing.T) {
+	for _, tc := range []struct {
+		patterns []string
+		value    string
+		expected bool
+	}{
+		{nil, "eu-west-1", true},
+		{[]string{"eu-*"}, "EU-West-1", true},
+		{[]string{"us-*", "EU-WEST-?"}, "eu-west-1", true},
+		{[]string{"eu-*"}, "us-east-1", false},
+		{[]string{"["}, "[", false}, // invalid pattern
+	} {
+		if got := match.Glob(tc.patterns, tc.value); got != tc.expected {
+			t.Errorf("%q %q: got %t", tc.patterns, tc.value, got)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package ip2asn reads the IP to ASN tables published by https://iptoasn.com/,
+// which map IPv4 ranges to the autonomous system (an ISP, a hosting provider...)
+// that announces them.
+//
+// Files: https://iptoasn.com/data/ip2asn-v4.tsv.gz or ip2asn-combined.tsv.gz
+// (uncompressed). Each line is a range:
+//
+//	range_start	range_end	AS_number	country_code	AS_description
+package ip2asn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+// Filter selects the ranges of a table.
+//
+// A range is selected if its AS number is one of ASNs (if not empty) and if its
+// AS description matches Description (if not nil). The ranges that are not
+// routed have AS number 0.
+type Filter struct {
+	ASNs        []uint32
+	Description *regexp.Regexp
+}
+
+func (f Filter) match(asn uint32, description string) bool {
+	if len(f.ASNs) > 0 {
+		found := false
+		for _, a := range f.ASNs {
+			if a == asn {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return f.Description == nil || f.Description.MatchString(description)
+}
+
+// ParseASN parses an AS number, with or without the "AS" prefix (ex: AS13335).
+func ParseASN(s string) (uint32, error) {
+	if len(s) > 2 && strings.EqualFold(s[:2], "AS") {
+		s = s[2:]
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("%q: invalid AS number", s)
+	}
+	return uint32(n), nil
+}
+
+// Read imports the IPv4 ranges of a table matching f. IPv6 ranges are ignored.
+//
+// The ranges are returned as listed, without merging adjacent ranges.
+func Read(r io.Reader, f Filter) ([]cidr2hcmask.IPv4Range, error) {
+	var ranges []cidr2hcmask.IPv4Range
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 5)
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("line %d: %d fields, 5 expected", lineNum, len(fields))
+		}
+		start, end, asnStr, description := fields[0], fields[1], fields[2], fields[4]
+		if strings.Contains(start, ":") {
+			continue
+		}
+		asn, err := strconv.ParseUint(asnStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %q: invalid AS number", lineNum, asnStr)
+		}
+		if !f.match(uint32(asn), description) {
+			continue
+		}
+
+		first, err := netip.ParseAddr(start)
+		if err != nil || !first.Is4() {
+			return nil, fmt.Errorf("line %d: %q: invalid IPv4 address", lineNum, start)
+		}
+		last, err := netip.ParseAddr(end)
+		if err != nil || !last.Is4() || last.Less(first) {
+			return nil, fmt.Errorf("line %d: %q: invalid IPv4 address", lineNum, end)
+		}
+		ranges = append(ranges, cidr2hcmask.IPv4Range{First: first.As4(), Last: last.As4()})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
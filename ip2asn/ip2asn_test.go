@@ -0,0 +1,88 @@
+package ip2asn_test
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+	"github.com/dolmen-go/cidr2hcmask/ip2asn"
+)
+
+const table = "0.0.0.0\t0.255.255.255\t0\tNone\tNot routed\n" +
+	"1.0.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET\n" +
+	"1.0.1.0\t1.0.3.255\t0\tNone\tNot routed\n" +
+	"1.0.4.0\t1.0.5.255\t38803\tAU\tWPL-AS-AP Wirefreebroadband Pty Ltd\n" +
+	"1.0.6.0\t1.0.7.255\t38803\tAU\tWPL-AS-AP Wirefreebroadband Pty Ltd\n" +
+	"1.1.1.0\t1.1.1.255\t13335\tUS\tCLOUDFLARENET\n" +
+	"2.0.0.0\t2.15.255.255\t3215\tFR\tFranceTelecom-Orange\n" +
+	"2001:200::\t2001:200:5ff:ffff:ffff:ffff:ffff:ffff\t2500\tJP\tWIDE-BB WIDE Project\n"
+
+func TestRead(t *testing.T) {
+	for _, tc := range []struct {
+		filter   ip2asn.Filter
+		expected string
+	}{
+		{ip2asn.Filter{ASNs: []uint32{13335}}, "1.0.0.0-1.0.0.255,1.1.1.0-1.1.1.255"},
+		{ip2asn.Filter{ASNs: []uint32{38803, 3215}}, "1.0.4.0-1.0.7.255,2.0.0.0-2.15.255.255"},
+		{ip2asn.Filter{Description: regexp.MustCompile(`(?i)orange`)}, "2.0.0.0-2.15.255.255"},
+		{ip2asn.Filter{ASNs: []uint32{13335}, Description: regexp.MustCompile(`Orange`)}, ""},
+		{ip2asn.Filter{ASNs: []uint32{2500}}, ""},
+	} {
+		ranges, err := ip2asn.Read(strings.NewReader(table), tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cidr2hcmask.NewIPv4Set(ranges...).String(); got != tc.expected {
+			t.Errorf("%+v: got %s, expected %s", tc.filter, got, tc.expected)
+		}
+	}
+}
+
+func TestReadRanges(t *testing.T) {
+	ranges, err := ip2asn.Read(strings.NewReader(table), ip2asn.Filter{ASNs: []uint32{38803}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Ranges are not merged
+	if got := fmt.Sprint(ranges); got != "[1.0.4.0-1.0.5.255 1.0.6.0-1.0.7.255]" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, tc := range []string{
+		"1.0.0.0\t1.0.0.255\t13335\tUS",
+		"1.0.0\t1.0.0.255\t13335\tUS\tCLOUDFLARENET",
+		"1.0.0.0\t1.0.0.256\t13335\tUS\tCLOUDFLARENET",
+		"1.0.0.255\t1.0.0.0\t13335\tUS\tCLOUDFLARENET",
+		"1.0.0.0\t1.0.0.255\tAS13335\tUS\tCLOUDFLARENET",
+	} {
+		if _, err := ip2asn.Read(strings.NewReader(tc), ip2asn.Filter{}); err == nil {
+			t.Errorf("%q: error expected", tc)
+		} else {
+			t.Log(err)
+		}
+	}
+}
+
+func TestParseASN(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		asn uint32
+		ok  bool
+	}{
+		{"13335", 13335, true},
+		{"AS13335", 13335, true},
+		{"as3215", 3215, true},
+		{"AS", 0, false},
+		{"ASx", 0, false},
+		{"4294967296", 0, false},
+	} {
+		asn, err := ip2asn.ParseASN(tc.in)
+		if (err == nil) != tc.ok || asn != tc.asn {
+			t.Errorf("%q: got %d, %v", tc.in, asn, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package cidr2hcmask
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// IPv4NetFromPrefix converts p to an [IPv4Net]. IPv4-mapped IPv6 prefixes
+// (::ffff:0:0/96 and longer) are unmapped.
+//
+// Errors returned (check with [errors.Is]): [ErrSyntax] (not an IPv4 prefix), [ErrNonZeroBits]
+func IPv4NetFromPrefix(p netip.Prefix) (IPv4Net, error) {
+	if !p.IsValid() {
+		return IPv4Net{}, ErrSyntax
+	}
+	addr, bits := p.Addr(), p.Bits()
+	if addr.Is4In6() {
+		if bits < 96 {
+			return IPv4Net{}, ErrSyntax
+		}
+		addr, bits = addr.Unmap(), bits-96
+	}
+	if !addr.Is4() {
+		return IPv4Net{}, ErrSyntax
+	}
+	net := IPv4Net{IP: addr.As4(), Bits: bits}
+	if m := netip.PrefixFrom(addr, bits).Masked(); m.Addr() != addr {
+		net.IP = m.Addr().As4()
+		return IPv4Net{}, fmt.Errorf("%s: %w (%s expected)", p, ErrNonZeroBits, net)
+	}
+	return net, nil
+}
+
+// Prefix converts net to a [netip.Prefix].
+func (net IPv4Net) Prefix() netip.Prefix {
+	return netip.PrefixFrom(netip.AddrFrom4(net.IP), net.Bits)
+}
+
+// hostMask returns the mask of the host bits.
+func (net IPv4Net) hostMask() uint32 {
+	return uint32(uint64(1)<<(32-net.Bits) - 1)
+}
+
+// First returns the first address of the block (the network address).
+func (net IPv4Net) First() [4]byte {
+	return net.IP
+}
+
+// Last returns the last address of the block (the broadcast address).
+func (net IPv4Net) Last() [4]byte {
+	return uint32ToIPv4(ipv4ToUint32(net.IP) | net.hostMask())
+}
+
+// Size returns the count of addresses in the block.
+func (net IPv4Net) Size() uint64 {
+	return uint64(1) << (32 - net.Bits)
+}
+
+// Range returns the block as an [IPv4Range].
+func (net IPv4Net) Range() IPv4Range {
+	return IPv4Range{First: net.First(), Last: net.Last()}
+}
+
+// Contains reports whether ip is in the block.
+func (net IPv4Net) Contains(ip [4]byte) bool {
+	return ipv4ToUint32(ip)&^net.hostMask() == ipv4ToUint32(net.IP)
+}
+
+// Overlaps reports whether the blocks have addresses in common (one of the blocks
+// contains the other).
+func (net IPv4Net) Overlaps(other IPv4Net) bool {
+	if other.Bits < net.Bits {
+		return other.Contains(net.IP)
+	}
+	return net.Contains(other.IP)
+}
+
+// MaxSplitBits is the maximum count of prefix bits that [IPv4Net.Split] adds, which
+// bounds the result to 65536 subnets.
+const MaxSplitBits = 16
+
+// MaxHostsSubnets is the maximum count of subnets that [IPv4Set.Hosts] handles.
+const MaxHostsSubnets = 1 << 20
+
+// ErrTooManySubnets is returned by [IPv4Net.Split] for a split deeper than
+// [MaxSplitBits], and by [IPv4Set.Hosts] beyond [MaxHostsSubnets] subnets.
+var ErrTooManySubnets = errors.New("too many subnets")
+
+// Split returns the subnets of net with prefix length bits, in ascending order.
+// bits must be between net.Bits and 32, and at most [MaxSplitBits] above net.Bits:
+// use [IPv4Net.SplitFunc] for deeper splits.
+//
+// Errors returned (check with [errors.Is]): [ErrTooManySubnets]
+func (net IPv4Net) Split(bits int) ([]IPv4Net, error) {
+	if bits < net.Bits || bits > 32 {
+		return nil, fmt.Errorf("%s: invalid prefix length /%d for a split", net, bits)
+	}
+	if bits-net.Bits > MaxSplitBits {
+		return nil, fmt.Errorf("%s: split to /%d: %w", net, bits, ErrTooManySubnets)
+	}
+	subnets := make([]IPv4Net, 0, 1<<(bits-net.Bits))
+	net.SplitFunc(bits, func(subnet IPv4Net) {
+		subnets = append(subnets, subnet)
+	})
+	return subnets, nil
+}
+
+// SplitFunc calls cb with each subnet of net with prefix length bits, in ascending
+// order. cb is not called if bits is lower than net.Bits or above 32.
+func (net IPv4Net) SplitFunc(bits int, cb func(subnet IPv4Net)) {
+	if bits < net.Bits || bits > 32 {
+		return
+	}
+	n := uint64(1) << (bits - net.Bits)
+	ip := uint64(ipv4ToUint32(net.IP))
+	step := uint64(1) << (32 - bits)
+	for i := uint64(0); i < n; i++ {
+		cb(IPv4Net{IP: uint32ToIPv4(uint32(ip)), Bits: bits})
+		ip += step
+	}
+}
+
+// Supernet returns the block with a prefix one bit shorter that contains net.
+// The supernet of 0.0.0.0/0 is itself.
+func (net IPv4Net) Supernet() IPv4Net {
+	if net.Bits == 0 {
+		return net
+	}
+	super := IPv4Net{Bits: net.Bits - 1}
+	super.IP = uint32ToIPv4(ipv4ToUint32(net.IP) &^ super.hostMask())
+	return super
+}
+
+// Compare returns an integer comparing net and other: blocks are ordered by
+// address, then by prefix length (a block comes before its subnets).
+// The result is 0 if net == other, -1 if net < other, and +1 if net > other.
+func (net IPv4Net) Compare(other IPv4Net) int {
+	a, b := ipv4ToUint32(net.IP), ipv4ToUint32(other.IP)
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	case net.Bits < other.Bits:
+		return -1
+	case net.Bits > other.Bits:
+		return 1
+	}
+	return 0
+}
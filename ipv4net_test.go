@@ -0,0 +1,146 @@
+package cidr2hcmask_test
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"sort"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func mustParseCIDR(s string) cidr2hcmask.IPv4Net {
+	net, err := cidr2hcmask.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return net
+}
+
+func TestIPv4NetPrefix(t *testing.T) {
+	for _, s := range []string{"0.0.0.0/0", "10.0.0.0/8", "192.168.1.0/24", "192.168.1.1/32"} {
+		p := mustParseCIDR(s).Prefix()
+		if p.String() != s {
+			t.Errorf("%s: got %s", s, p)
+		}
+		net, err := cidr2hcmask.IPv4NetFromPrefix(p)
+		if err != nil || net.String() != s {
+			t.Errorf("%s: got %s, %v", s, net, err)
+		}
+	}
+
+	net, err := cidr2hcmask.IPv4NetFromPrefix(netip.MustParsePrefix("::ffff:192.168.0.0/112"))
+	if err != nil || net.String() != "192.168.0.0/16" {
+		t.Errorf("IPv4-mapped: got %s, %v", net, err)
+	}
+	if _, err := cidr2hcmask.IPv4NetFromPrefix(netip.MustParsePrefix("2001:db8::/32")); !errors.Is(err, cidr2hcmask.ErrSyntax) {
+		t.Errorf("IPv6: ErrSyntax expected, got %v", err)
+	}
+	if _, err := cidr2hcmask.IPv4NetFromPrefix(netip.MustParsePrefix("192.168.0.1/24")); !errors.Is(err, cidr2hcmask.ErrNonZeroBits) {
+		t.Errorf("ErrNonZeroBits expected, got %v", err)
+	}
+}
+
+func TestIPv4NetArithmetic(t *testing.T) {
+	net := mustParseCIDR("192.168.4.0/22")
+	if got := net.First(); got != [4]byte{192, 168, 4, 0} {
+		t.Errorf("First: got %v", got)
+	}
+	if got := net.Last(); got != [4]byte{192, 168, 7, 255} {
+		t.Errorf("Last: got %v", got)
+	}
+	if got := net.Size(); got != 1024 {
+		t.Errorf("Size: got %d", got)
+	}
+	if got := mustParseCIDR("0.0.0.0/0").Size(); got != 1<<32 {
+		t.Errorf("Size /0: got %d", got)
+	}
+	if got := mustParseCIDR("0.0.0.0/0").Last(); got != [4]byte{255, 255, 255, 255} {
+		t.Errorf("Last /0: got %v", got)
+	}
+	if got := net.Range().String(); got != "192.168.4.0-192.168.7.255" {
+		t.Errorf("Range: got %s", got)
+	}
+
+	for _, tc := range []struct {
+		ip       [4]byte
+		expected bool
+	}{
+		{[4]byte{192, 168, 4, 0}, true},
+		{[4]byte{192, 168, 7, 255}, true},
+		{[4]byte{192, 168, 3, 255}, false},
+		{[4]byte{192, 168, 8, 0}, false},
+	} {
+		if got := net.Contains(tc.ip); got != tc.expected {
+			t.Errorf("Contains(%v): got %t", tc.ip, got)
+		}
+	}
+
+	if got, err := net.Split(24); err != nil || fmt.Sprint(got) != "[192.168.4.0/24 192.168.5.0/24 192.168.6.0/24 192.168.7.0/24]" {
+		t.Errorf("Split: got %s, %v", got, err)
+	}
+	if got, err := net.Split(21); err == nil {
+		t.Errorf("Split(21): got %v", got)
+	}
+	all := mustParseCIDR("0.0.0.0/0")
+	if got, err := all.Split(16); err != nil || len(got) != 65536 {
+		t.Errorf("Split(16) /0: got %d subnets, %v", len(got), err)
+	}
+	if got, err := all.Split(32); !errors.Is(err, cidr2hcmask.ErrTooManySubnets) {
+		t.Errorf("Split(32) /0: got %d subnets, %v", len(got), err)
+	}
+	var count int
+	var last cidr2hcmask.IPv4Net
+	mustParseCIDR("10.0.0.0/8").SplitFunc(32, func(subnet cidr2hcmask.IPv4Net) {
+		count++
+		last = subnet
+	})
+	if count != 1<<24 || last.String() != "10.255.255.255/32" {
+		t.Errorf("SplitFunc(32) /8: got %d subnets, last %s", count, last)
+	}
+
+	if got := net.Supernet().String(); got != "192.168.0.0/21" {
+		t.Errorf("Supernet: got %s", got)
+	}
+	if got := mustParseCIDR("0.0.0.0/0").Supernet().String(); got != "0.0.0.0/0" {
+		t.Errorf("Supernet /0: got %s", got)
+	}
+
+	for _, tc := range []struct {
+		other    string
+		expected bool
+	}{
+		{"192.168.0.0/16", true},
+		{"192.168.5.128/25", true},
+		{"192.168.4.0/22", true},
+		{"192.168.8.0/22", false},
+		{"192.168.0.0/22", false},
+	} {
+		if got := net.Overlaps(mustParseCIDR(tc.other)); got != tc.expected {
+			t.Errorf("Overlaps(%s): got %t", tc.other, got)
+		}
+		if got := mustParseCIDR(tc.other).Overlaps(net); got != tc.expected {
+			t.Errorf("%s.Overlaps: got %t", tc.other, got)
+		}
+	}
+}
+
+func TestIPv4NetCompare(t *testing.T) {
+	nets := []cidr2hcmask.IPv4Net{
+		mustParseCIDR("192.168.1.0/24"),
+		mustParseCIDR("10.0.0.0/16"),
+		mustParseCIDR("192.168.0.0/16"),
+		mustParseCIDR("10.0.0.0/8"),
+		mustParseCIDR("192.168.0.0/24"),
+	}
+	sort.Slice(nets, func(i, j int) bool {
+		return nets[i].Compare(nets[j]) < 0
+	})
+	if got := fmt.Sprint(nets); got != "[10.0.0.0/8 10.0.0.0/16 192.168.0.0/16 192.168.0.0/24 192.168.1.0/24]" {
+		t.Errorf("got %s", got)
+	}
+	if c := nets[0].Compare(nets[0]); c != 0 {
+		t.Errorf("got %d", c)
+	}
+}
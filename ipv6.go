@@ -0,0 +1,294 @@
+package cidr2hcmask
+
+import (
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IPv6Net represents a block of IPv6 addresses using a base IP address and a count of bits.
+type IPv6Net struct {
+	IP   [16]byte
+	Bits int
+}
+
+// String uses the CIDR format <ip>/<bits>, with the address in the canonical text
+// form defined by [RFC 5952].
+//
+// String implements interface [fmt.Stringer].
+//
+// [RFC 5952]: https://www.rfc-editor.org/rfc/rfc5952
+func (net IPv6Net) String() string {
+	return net.Prefix().String()
+}
+
+// ParseIPv6CIDR parses an IPv6 network address in CIDR notation.
+//
+// Exemple value: 2001:db8:1234::/48.
+//
+// Errors returned (check with [errors.Is]): [ErrSyntax], [ErrNonZeroBits]
+func ParseIPv6CIDR(s string) (IPv6Net, error) {
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return IPv6Net{}, ErrSyntax
+	}
+	return IPv6NetFromPrefix(p)
+}
+
+// IPv6NetFromPrefix converts p to an [IPv6Net].
+//
+// Errors returned (check with [errors.Is]): [ErrSyntax] (not an IPv6 prefix), [ErrNonZeroBits]
+func IPv6NetFromPrefix(p netip.Prefix) (IPv6Net, error) {
+	if !p.IsValid() || !p.Addr().Is6() || p.Addr().Zone() != "" {
+		return IPv6Net{}, ErrSyntax
+	}
+	net := IPv6Net{IP: p.Addr().As16(), Bits: p.Bits()}
+	if m := p.Masked(); m != p {
+		net.IP = m.Addr().As16()
+		return IPv6Net{}, fmt.Errorf("%s: %w (%s expected)", p, ErrNonZeroBits, net)
+	}
+	return net, nil
+}
+
+// Prefix converts net to a [netip.Prefix].
+func (net IPv6Net) Prefix() netip.Prefix {
+	return netip.PrefixFrom(netip.AddrFrom16(net.IP), net.Bits)
+}
+
+// AggregateIPv6 returns the minimal sorted list of networks that cover the same
+// addresses as nets: networks contained in another one are removed, and adjacent
+// networks are merged into their supernet.
+func AggregateIPv6(nets []IPv6Net) []IPv6Net {
+	prefixes := make([]netip.Prefix, len(nets))
+	for i, net := range nets {
+		prefixes[i] = net.Prefix()
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		if c := prefixes[i].Addr().Compare(prefixes[j].Addr()); c != 0 {
+			return c < 0
+		}
+		return prefixes[i].Bits() < prefixes[j].Bits()
+	})
+
+	var stack []netip.Prefix
+	for _, p := range prefixes {
+		if n := len(stack); n > 0 && stack[n-1].Contains(p.Addr()) && stack[n-1].Bits() <= p.Bits() {
+			continue
+		}
+		stack = append(stack, p)
+		// Merge siblings
+		for n := len(stack); n >= 2; n = len(stack) {
+			a, b := stack[n-2], stack[n-1]
+			if a.Bits() != b.Bits() || a.Bits() == 0 {
+				break
+			}
+			parent := netip.PrefixFrom(a.Addr(), a.Bits()-1).Masked()
+			if parent.Addr() != a.Addr() || !parent.Contains(b.Addr()) {
+				break
+			}
+			stack = append(stack[:n-2], parent)
+		}
+	}
+
+	result := make([]IPv6Net, len(stack))
+	for i, p := range stack {
+		result[i] = IPv6Net{IP: p.Addr().As16(), Bits: p.Bits()}
+	}
+	return result
+}
+
+// hexNonZero is the charset of the first digit of a group of more than one digit.
+const hexNonZero = "123456789abcdef"
+
+// ipv6Choice is one of the text forms of the values of a 16-bits group.
+type ipv6Choice struct {
+	Zero    bool   // the group is 0 and may be compressed with "::"
+	Charset string // custom charset used by Mask as ?2 (empty if none)
+	Mask    string
+}
+
+// ipv6Nibble is the set of values [Lo, Lo+Count-1] of an hex digit.
+type ipv6Nibble struct {
+	Lo, Count uint8
+}
+
+func (n ipv6Nibble) mask(charset *string) string {
+	switch n.Count {
+	case 1:
+		return strconv.FormatUint(uint64(n.Lo), 16)
+	case 16:
+		return "?h"
+	}
+	var b [16]byte
+	for i := uint8(0); i < n.Count; i++ {
+		b[i] = "0123456789abcdef"[n.Lo+i]
+	}
+	*charset = string(b[:n.Count])
+	return "?2"
+}
+
+// ipv6GroupChoices lists the text forms (without leading zeros) of the values of a
+// group whose first bits are fixed to the ones of value.
+func ipv6GroupChoices(value uint16, bits int) []ipv6Choice {
+	var nibbles [4]ipv6Nibble // high nibble first
+	for q := 0; q < 4; q++ {
+		f := bits - 4*q
+		if f < 0 {
+			f = 0
+		} else if f > 4 {
+			f = 4
+		}
+		n := uint8(value>>(12-4*q)) & 0xf
+		size := uint8(1) << (4 - f)
+		nibbles[q] = ipv6Nibble{Lo: n &^ (size - 1), Count: size}
+	}
+
+	var choices []ipv6Choice
+	if canBeZero(nibbles[:]) {
+		choices = append(choices, ipv6Choice{Zero: true, Mask: "0"})
+	}
+	// Text forms by count of digits (the digits before the leading one must be zero)
+	for q := 3; q >= 0; q-- {
+		if !canBeZero(nibbles[:q]) {
+			continue
+		}
+		lead := nibbles[q]
+		if lead.Lo == 0 {
+			if lead.Count == 1 {
+				continue
+			}
+			lead.Lo = 1
+			lead.Count--
+		}
+		var c ipv6Choice
+		var mask []byte
+		if lead.Lo == 1 && lead.Count == 15 {
+			mask = append(mask, "?1"...)
+		} else {
+			mask = append(mask, lead.mask(&c.Charset)...)
+		}
+		for _, n := range nibbles[q+1:] {
+			mask = append(mask, n.mask(&c.Charset)...)
+		}
+		c.Mask = string(mask)
+		choices = append(choices, c)
+	}
+	return choices
+}
+
+func canBeZero(nibbles []ipv6Nibble) bool {
+	for _, n := range nibbles {
+		if n.Lo != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func ipv6CIDR2HCMask(net IPv6Net) [8][]ipv6Choice {
+	var groups [8][]ipv6Choice
+	bits := net.Bits
+	for i := 0; i < 8; i++ {
+		groups[i] = ipv6GroupChoices(uint16(net.IP[2*i])<<8|uint16(net.IP[2*i+1]), bits)
+		if bits > 16 {
+			bits -= 16
+		} else {
+			bits = 0
+		}
+	}
+	return groups
+}
+
+func expandIPv6(groups [8][]ipv6Choice, upper bool, cb func(mask string)) {
+	var path [8]*ipv6Choice
+	var rec func(i int)
+	rec = func(i int) {
+		if i < 8 {
+			for j := range groups[i] {
+				path[i] = &groups[i][j]
+				rec(i + 1)
+			}
+			return
+		}
+
+		// RFC 5952 section 4.2: compress the first longest run of at least 2 zero groups
+		start, length := -1, 1
+		for j := 0; j < 8; {
+			if !path[j].Zero {
+				j++
+				continue
+			}
+			k := j + 1
+			for k < 8 && path[k].Zero {
+				k++
+			}
+			if k-j > length {
+				start, length = j, k-j
+			}
+			j = k
+		}
+
+		b := make([]byte, 0, len(hexNonZero)+1+16+1+8*(4*2+1))
+		b = append(append(b, hexNonZero...), ',')
+		for _, c := range path {
+			if c.Charset != "" {
+				b = append(append(b, c.Charset...), ',')
+				break
+			}
+		}
+		for j := 0; j < 8; j++ {
+			if j == start {
+				b = append(b, "::"...)
+				j += length - 1
+				continue
+			}
+			if j > 0 && j != start+length {
+				b = append(b, ':')
+			}
+			b = append(b, path[j].Mask...)
+		}
+		mask := string(b)
+		if upper {
+			mask = strings.ToUpper(mask)
+		}
+		cb(mask)
+	}
+	rec(0)
+}
+
+// IPv6CIDR2HCMaskFunc calls cb with each hashcat mask of the set of masks that match
+// the addresses of net written in the canonical text form of [RFC 5952]
+// (lowercase, no leading zeros, longest run of zero groups compressed as "::").
+//
+// Charset ?1 is always the non-zero hex digits. Charset ?2 is a custom charset used
+// for the hex digit where the prefix ends inside a nibble.
+//
+// The mixed notation for IPv4-mapped addresses (RFC 5952 section 5) is not produced.
+//
+// [RFC 5952]: https://www.rfc-editor.org/rfc/rfc5952
+func IPv6CIDR2HCMaskFunc(net IPv6Net, cb func(mask string)) {
+	expandIPv6(ipv6CIDR2HCMask(net), false, cb)
+}
+
+// IPv6CIDR2HCMaskUpperFunc is like [IPv6CIDR2HCMaskFunc] but for the uppercase
+// variant of the text form (?H instead of ?h).
+func IPv6CIDR2HCMaskUpperFunc(net IPv6Net, cb func(mask string)) {
+	expandIPv6(ipv6CIDR2HCMask(net), true, cb)
+}
+
+func IPv6CIDR2HCMask(net IPv6Net) []string {
+	var masks []string
+	IPv6CIDR2HCMaskFunc(net, func(mask string) {
+		masks = append(masks, mask)
+	})
+	return masks
+}
+
+func IPv6CIDR2HCMaskWrite(net IPv6Net, w io.Writer) error {
+	return writeMasks(w, func(cb func(string)) {
+		IPv6CIDR2HCMaskFunc(net, cb)
+	})
+}
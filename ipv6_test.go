@@ -0,0 +1,181 @@
+package cidr2hcmask_test
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func TestParseIPv6CIDR(t *testing.T) {
+	for _, tc := range []struct {
+		in, out string
+	}{
+		{"2001:db8:1234::/48", "2001:db8:1234::/48"},
+		{"2001:0DB8:1234:0000::/48", "2001:db8:1234::/48"},
+		{"::/0", "::/0"},
+		{"fe80::1/128", "fe80::1/128"},
+	} {
+		net, err := cidr2hcmask.ParseIPv6CIDR(tc.in)
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		if net.String() != tc.out {
+			t.Errorf("%q: got %q, expected %q", tc.in, net, tc.out)
+		}
+	}
+
+	for _, tc := range []string{"192.168.0.0/24", "2001:db8::", "2001:db8::/129", "fe80::%eth0/64"} {
+		if _, err := cidr2hcmask.ParseIPv6CIDR(tc); !errors.Is(err, cidr2hcmask.ErrSyntax) {
+			t.Errorf("%q: ErrSyntax expected, got %v", tc, err)
+		}
+	}
+	if _, err := cidr2hcmask.ParseIPv6CIDR("2001:db8::1/64"); !errors.Is(err, cidr2hcmask.ErrNonZeroBits) {
+		t.Errorf("ErrNonZeroBits expected, got %v", err)
+	}
+}
+
+// checkIPv6Expand checks that the masks for cidr match exactly the canonical text
+// form of each address of the network.
+func checkIPv6Expand(t *testing.T, cidr string, upper bool) {
+	t.Helper()
+	net, err := cidr2hcmask.ParseIPv6CIDR(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := netip.MustParsePrefix(cidr)
+	expected := make(map[string]bool)
+	for a := p.Addr(); p.Contains(a); a = a.Next() {
+		s := a.String()
+		if upper {
+			s = strings.ToUpper(s)
+		}
+		expected[s] = false
+	}
+
+	gen := cidr2hcmask.IPv6CIDR2HCMaskFunc
+	if upper {
+		gen = cidr2hcmask.IPv6CIDR2HCMaskUpperFunc
+	}
+	gen(net, func(mask string) {
+		t.Log(mask)
+		HCMaskExpand(mask, func(b []byte) {
+			seen, ok := expected[string(b)]
+			if !ok {
+				t.Errorf("%s: unexpected %q", mask, b)
+			} else if seen {
+				t.Errorf("%s: duplicate %q", mask, b)
+			}
+			expected[string(b)] = true
+		})
+	})
+	for s, seen := range expected {
+		if !seen {
+			t.Errorf("%s: %q not matched", cidr, s)
+		}
+	}
+}
+
+func TestIPv6CIDR2HCMaskExpand(t *testing.T) {
+	for _, cidr := range []string{
+		"2001:db8::/120",
+		"2001:db8::/116",
+		"2001:db8::1:0/114",
+		"2001:db8::a:0/116",
+		"2001:db8:0:0:1::/118",
+		"::/116",
+		"::ffff:0/119",
+		"fe80::1/128",
+		"2001:db8::8000/113",
+	} {
+		checkIPv6Expand(t, cidr, false)
+	}
+	checkIPv6Expand(t, "2001:db8::ab00/120", true)
+}
+
+func TestIPv6CIDR2HCMaskCount(t *testing.T) {
+	net, err := cidr2hcmask.ParseIPv6CIDR("2001:db8:1234::/48")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 5 text forms for each of the 5 free groups: 0, ?1, ?1?h, ?1?h?h, ?1?h?h?h
+	if n := len(cidr2hcmask.IPv6CIDR2HCMask(net)); n != 5*5*5*5*5 {
+		t.Errorf("got %d masks", n)
+	}
+}
+
+func ExampleIPv6CIDR2HCMaskFunc() {
+	net, err := cidr2hcmask.ParseIPv6CIDR("2001:db8::ab00/120")
+	if err != nil {
+		panic(err)
+	}
+
+	cidr2hcmask.IPv6CIDR2HCMaskFunc(net, func(mask string) {
+		fmt.Println(mask)
+	})
+
+	// Output:
+	// 123456789abcdef,2001:db8::ab?h?h
+}
+
+func ExampleIPv6CIDR2HCMaskWrite() {
+	net, err := cidr2hcmask.ParseIPv6CIDR("2001:db8::1:0/126")
+	if err != nil {
+		panic(err)
+	}
+
+	cidr2hcmask.IPv6CIDR2HCMaskWrite(net, os.Stdout)
+
+	// Output:
+	// 123456789abcdef,2001:db8::1:0
+	// 123456789abcdef,123,2001:db8::1:?2
+}
+
+func TestAggregateIPv6(t *testing.T) {
+	var nets []cidr2hcmask.IPv6Net
+	for _, s := range []string{
+		"2001:db8:1::/48",
+		"2001:db8::/48",
+		"2001:db8:1:2::/64", // Contained
+		"2001:db8:2::/48",
+		"2001:db8:3::/48",
+		"2001:db8:5::/48",
+		"2001:db8:1::/48", // Duplicate
+		"fe80::/10",
+	} {
+		net, err := cidr2hcmask.ParseIPv6CIDR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nets = append(nets, net)
+	}
+	got := fmt.Sprint(cidr2hcmask.AggregateIPv6(nets))
+	if got != "[2001:db8::/46 2001:db8:5::/48 fe80::/10]" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestIPv6NetPrefix(t *testing.T) {
+	for _, s := range []string{"2001:db8::/32", "::/0", "2001:db8::1/128"} {
+		p := netip.MustParsePrefix(s)
+		net, err := cidr2hcmask.IPv6NetFromPrefix(p)
+		if err != nil {
+			t.Errorf("%s: %v", s, err)
+			continue
+		}
+		if net.Prefix() != p {
+			t.Errorf("%s: got %s", s, net.Prefix())
+		}
+	}
+	if _, err := cidr2hcmask.IPv6NetFromPrefix(netip.MustParsePrefix("2001:db8::1/32")); !errors.Is(err, cidr2hcmask.ErrNonZeroBits) {
+		t.Errorf("ErrNonZeroBits expected, got %v", err)
+	}
+	if _, err := cidr2hcmask.IPv6NetFromPrefix(netip.MustParsePrefix("10.0.0.0/8")); !errors.Is(err, cidr2hcmask.ErrSyntax) {
+		t.Errorf("IPv4: ErrSyntax expected, got %v", err)
+	}
+}
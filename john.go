@@ -0,0 +1,184 @@
+package cidr2hcmask
+
+import (
+	"io"
+	"strings"
+)
+
+// johnCharset returns the John the Ripper mask of a charset of digits: a list of
+// digits, with runs of 3 digits or more as ranges, in brackets.
+func johnCharset(digits string) string {
+	b := make([]byte, 0, 2+len(digits))
+	b = append(b, '[')
+	for i := 0; i < len(digits); {
+		j := i + 1
+		for j < len(digits) && digits[j] == digits[j-1]+1 {
+			j++
+		}
+		if j-i >= 3 {
+			b = append(b, digits[i], '-', digits[j-1])
+		} else {
+			b = append(b, digits[i:j]...)
+		}
+		i = j
+	}
+	return string(append(b, ']'))
+}
+
+// johnPositions converts the hashcat mask of an octet (see [lookup]) to the
+// digits allowed at each position.
+func johnPositions(mask string) []string {
+	var custom string
+	if p := strings.IndexByte(mask, ','); p > 0 {
+		custom, mask = mask[:p], mask[p+1:]
+	}
+	positions := make([]string, 0, 3)
+	for i := 0; i < len(mask); i++ {
+		if mask[i] != '?' {
+			positions = append(positions, mask[i:i+1])
+			continue
+		}
+		i++
+		switch mask[i] {
+		case '1':
+			positions = append(positions, string(cs04))
+		case '2':
+			positions = append(positions, string(cs05))
+		case '3':
+			positions = append(positions, string(cs19))
+		case '4':
+			positions = append(positions, custom)
+		default: // ?d
+			positions = append(positions, "0123456789")
+		}
+	}
+	return positions
+}
+
+// mergeJohnPositions merges the masks of an octet that differ only by the digits
+// at one position. As the masks match disjoint sets of values, the merged mask
+// matches exactly their union.
+func mergeJohnPositions(masks [][]string) [][]string {
+	for merged := true; merged; {
+		merged = false
+	search:
+		for i := 0; i < len(masks); i++ {
+			for j := i + 1; j < len(masks); j++ {
+				if len(masks[i]) != len(masks[j]) {
+					continue
+				}
+				diff := -1
+				for k := range masks[i] {
+					if masks[i][k] != masks[j][k] {
+						if diff >= 0 {
+							diff = -2
+							break
+						}
+						diff = k
+					}
+				}
+				if diff < 0 {
+					continue
+				}
+				m := append([]string(nil), masks[i]...)
+				m[diff] = unionDigits(masks[i][diff], masks[j][diff])
+				masks[i] = m
+				masks = append(masks[:j], masks[j+1:]...)
+				merged = true
+				break search
+			}
+		}
+	}
+	return masks
+}
+
+// unionDigits returns the sorted union of two sets of digits.
+func unionDigits(a, b string) string {
+	var digits [10]bool
+	for _, s := range [2]string{a, b} {
+		for i := 0; i < len(s); i++ {
+			digits[s[i]-'0'] = true
+		}
+	}
+	u := make([]byte, 0, 10)
+	for d, ok := range digits {
+		if ok {
+			u = append(u, byte('0'+d))
+		}
+	}
+	return string(u)
+}
+
+// johnMask returns the John the Ripper mask of the digits of each position.
+func johnMask(positions []string) string {
+	var b strings.Builder
+	for _, digits := range positions {
+		switch {
+		case len(digits) == 1:
+			b.WriteString(digits)
+		case digits == "0123456789":
+			b.WriteString("?d")
+		default:
+			b.WriteString(johnCharset(digits))
+		}
+	}
+	return b.String()
+}
+
+// expandJohn calls cb with each John the Ripper mask of the cross product of the
+// octets masks. Unlike hashcat, John has no limit of custom charsets, so octets
+// are never enumerated, and the masks of an octet that differ by one position are
+// merged.
+func expandJohn(ipmask [4][]string, cb func(mask string)) {
+	var octets [4][]string
+	for i, masks := range ipmask {
+		positions := make([][]string, len(masks))
+		for j, mask := range masks {
+			positions[j] = johnPositions(mask)
+		}
+		for _, p := range mergeJohnPositions(positions) {
+			octets[i] = append(octets[i], johnMask(p))
+		}
+	}
+	for _, o0 := range octets[0] {
+		for _, o1 := range octets[1] {
+			for _, o2 := range octets[2] {
+				for _, o3 := range octets[3] {
+					cb(o0 + "." + o1 + "." + o2 + "." + o3)
+				}
+			}
+		}
+	}
+}
+
+// CIDR2JohnMaskFunc calls cb with each [John the Ripper mask] of the set of masks
+// that cover exactly the addresses of net.
+//
+// [John the Ripper mask]: https://github.com/openwall/john/blob/bleeding-jumbo/doc/MASK
+func CIDR2JohnMaskFunc(net IPv4Net, cb func(mask string)) {
+	expandJohn(cidr2hcmask(net), cb)
+}
+
+// Set2JohnMaskFunc calls cb with each [John the Ripper mask] of the set of masks
+// that cover exactly once the addresses of s.
+//
+// [John the Ripper mask]: https://github.com/openwall/john/blob/bleeding-jumbo/doc/MASK
+func Set2JohnMaskFunc(s IPv4Set, cb func(mask string)) {
+	spans2hcmask(s.spans, func(ipmask [4][]string) {
+		expandJohn(ipmask, cb)
+	})
+}
+
+func Set2JohnMask(s IPv4Set) []string {
+	var masks []string
+	Set2JohnMaskFunc(s, func(mask string) {
+		masks = append(masks, mask)
+	})
+	return masks
+}
+
+func Set2JohnMaskWrite(s IPv4Set, w io.Writer) error {
+	return writeMasks(w, func(cb func(string)) {
+		Set2JohnMaskFunc(s, cb)
+	})
+}
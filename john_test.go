@@ -0,0 +1,139 @@
+package cidr2hcmask_test
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+// JohnMaskExpand calls visit with each candidate of a John the Ripper mask using
+// only ?d and bracketed lists and ranges of digits.
+func JohnMaskExpand(mask string, visit func([]byte)) {
+	var positions []string // charset of each position
+	for i := 0; i < len(mask); i++ {
+		switch mask[i] {
+		case '?':
+			i++
+			if mask[i] != 'd' {
+				panic("unsupported: ?" + mask[i:i+1])
+			}
+			positions = append(positions, "0123456789")
+		case '[':
+			end := strings.IndexByte(mask[i:], ']') + i
+			var chars []byte
+			for j := i + 1; j < end; j++ {
+				if j+2 < end && mask[j+1] == '-' {
+					for c := mask[j]; c <= mask[j+2]; c++ {
+						chars = append(chars, c)
+					}
+					j += 2
+				} else {
+					chars = append(chars, mask[j])
+				}
+			}
+			positions = append(positions, string(chars))
+			i = end
+		default:
+			positions = append(positions, mask[i:i+1])
+		}
+	}
+	buf := make([]byte, len(positions))
+	var rec func(i int)
+	rec = func(i int) {
+		if i == len(positions) {
+			visit(buf)
+			return
+		}
+		for j := 0; j < len(positions[i]); j++ {
+			buf[i] = positions[i][j]
+			rec(i + 1)
+		}
+	}
+	rec(0)
+}
+
+func checkJohnExpand(t *testing.T, s cidr2hcmask.IPv4Set, masks []string) {
+	t.Helper()
+	seen := make(map[uint32]bool)
+	for _, mask := range masks {
+		if strings.ContainsAny(mask, ",") || strings.Contains(mask, "?1") {
+			t.Errorf("%s: not a John mask", mask)
+		}
+		JohnMaskExpand(mask, func(b []byte) {
+			ip, err := netip.ParseAddr(string(b))
+			if err != nil || !ip.Is4() {
+				t.Errorf("%s: %q: invalid IP", mask, b)
+				return
+			}
+			ip4 := ip.As4()
+			if !s.Contains(ip4) {
+				t.Errorf("%s: %q: not in set", mask, b)
+			}
+			n := binary.BigEndian.Uint32(ip4[:])
+			if seen[n] {
+				t.Errorf("%s: %q: duplicate", mask, b)
+			}
+			seen[n] = true
+		})
+	}
+	if uint64(len(seen)) != s.Size() {
+		t.Errorf("%s: %d addresses matched, expected %d", s, len(seen), s.Size())
+	}
+}
+
+func TestCIDR2JohnMask(t *testing.T) {
+	for _, tc := range []struct {
+		cidr     string
+		expected []string
+	}{
+		{"192.168.1.0/28", []string{"192.168.1.?d", "192.168.1.1[0-5]"}},
+		{"192.168.1.4/30", []string{"192.168.1.[4-7]"}},
+		{"10.0.0.1/32", []string{"10.0.0.1"}},
+	} {
+		var masks []string
+		cidr2hcmask.CIDR2JohnMaskFunc(mustParseCIDR(tc.cidr), func(mask string) {
+			masks = append(masks, mask)
+		})
+		if strings.Join(masks, " ") != strings.Join(tc.expected, " ") {
+			t.Errorf("%s: got %q, expected %q", tc.cidr, masks, tc.expected)
+		}
+	}
+
+	for _, cidr := range []string{"10.0.0.0/14", "172.16.0.0/20", "192.168.1.128/25"} {
+		net := mustParseCIDR(cidr)
+		var masks []string
+		cidr2hcmask.CIDR2JohnMaskFunc(net, func(mask string) {
+			masks = append(masks, mask)
+		})
+		if len(masks) > len(cidr2hcmask.CIDR2HCMask(net)) {
+			t.Errorf("%s: %d masks, expected %d", cidr, len(masks), len(cidr2hcmask.CIDR2HCMask(net)))
+		}
+		checkJohnExpand(t, cidr2hcmask.NewIPv4SetFromNets(net), masks)
+	}
+}
+
+func TestSet2JohnMask(t *testing.T) {
+	// Octets with different custom charsets: hashcat has a single ?4 slot
+	o, err := cidr2hcmask.ParseOctetRanges("10.0-3.0-2.1,3,5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := o.Set()
+	john := cidr2hcmask.Set2JohnMask(set)
+	hcmask := cidr2hcmask.Set2HCMask(set)
+	t.Log(john)
+	if len(john) >= len(hcmask) {
+		t.Errorf("%d John masks, %d hashcat masks", len(john), len(hcmask))
+	}
+	checkJohnExpand(t, set, john)
+
+	rnd := rand.New(rand.NewSource(3))
+	for i := 0; i < 20; i++ {
+		set, _ := randomSet(rnd)
+		checkJohnExpand(t, set, cidr2hcmask.Set2JohnMask(set))
+	}
+}
@@ -0,0 +1,69 @@
+package cidr2hcmask
+
+import (
+	"errors"
+	"strings"
+)
+
+// ParseCIDRLenient parses an IPv4 network address like [ParseCIDR], but also accepts
+// the messy forms found in scraped lists:
+//   - a bare IP address, as a /32: 192.168.0.1
+//   - an abbreviated address with missing trailing zero octets: 10/8, 172.16/12
+//   - a dotted netmask or a wildcard mask (see [ParseNetmask])
+//   - leading and trailing spaces
+//
+// If the address has bits set outside of the prefix, the canonical network is
+// returned with a non-nil warning that wraps [ErrNonZeroBits] and tells the
+// correction applied.
+//
+// Errors returned (check with [errors.Is]): [ErrSyntax], [ErrNonContiguousMask]
+func ParseCIDRLenient(s string) (net IPv4Net, warning error, err error) {
+	s = strings.TrimSpace(s)
+	if _, mask, found := strings.Cut(s, "/"); strings.Contains(s, " ") || (found && strings.Contains(mask, ".")) {
+		net, err = parseNetmask(s)
+	} else {
+		expanded := expandAbbreviatedCIDR(s)
+		net, err = parseCIDR(expanded)
+		if perr, ok := err.(*ParseError); ok && expanded != s {
+			restoreInput(perr, s)
+		}
+	}
+	if err != nil {
+		if errors.Is(err, ErrNonZeroBits) {
+			return net, err, nil
+		}
+		return IPv4Net{}, nil, err
+	}
+	return net, nil, nil
+}
+
+// restoreInput reports e, found on the expansion of s by [expandAbbreviatedCIDR],
+// on s. The expansion inserts text at the end of the address part of s, so the
+// offsets after it are shifted back, and the offsets inside it are moved to its
+// position.
+func restoreInput(e *ParseError, s string) {
+	at := strings.IndexByte(s, '/')
+	if at < 0 {
+		at = len(s)
+	}
+	if e.Offset > at {
+		e.Offset -= len(e.Input) - len(s)
+		if e.Offset < at {
+			e.Offset = at
+		}
+	}
+	e.Input = s
+}
+
+// expandAbbreviatedCIDR completes a bare address with /32 and an abbreviated
+// address with zero octets.
+func expandAbbreviatedCIDR(s string) string {
+	ipStr, bitsStr, found := strings.Cut(s, "/")
+	if !found {
+		return s + "/32"
+	}
+	if n := strings.Count(ipStr, "."); n < 3 && ipStr != "" && ipStr[len(ipStr)-1] != '.' {
+		return ipStr + strings.Repeat(".0", 3-n) + "/" + bitsStr
+	}
+	return s
+}
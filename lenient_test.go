@@ -0,0 +1,93 @@
+package cidr2hcmask_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func TestParseCIDRLenient(t *testing.T) {
+	for _, tc := range []struct {
+		in, out string
+		warn    bool
+	}{
+		{"192.168.0.0/16", "192.168.0.0/16", false},
+		{"192.168.0.1/16", "192.168.0.0/16", true},
+		{"0.0.0.1/0", "0.0.0.0/0", true},
+		{"192.168.0.1", "192.168.0.1/32", false},
+		{" 192.168.0.1 ", "192.168.0.1/32", false},
+		{"10/8", "10.0.0.0/8", false},
+		{"172.16/12", "172.16.0.0/12", false},
+		{"192.168.1/24", "192.168.1.0/24", false},
+		{"10.1/8", "10.0.0.0/8", true},
+		{"192.168.0.1/255.255.255.0", "192.168.0.0/24", true},
+		{"192.168.0.0 0.0.0.255", "192.168.0.0/24", false},
+	} {
+		net, warn, err := cidr2hcmask.ParseCIDRLenient(tc.in)
+		if err != nil {
+			t.Errorf("%q: %v", tc.in, err)
+			continue
+		}
+		if net.String() != tc.out {
+			t.Errorf("%q: got %q, expected %q", tc.in, net, tc.out)
+		}
+		if tc.warn {
+			if !errors.Is(warn, cidr2hcmask.ErrNonZeroBits) {
+				t.Errorf("%q: ErrNonZeroBits warning expected, got %v", tc.in, warn)
+			}
+		} else if warn != nil {
+			t.Errorf("%q: unexpected warning %q", tc.in, warn)
+		}
+	}
+}
+
+func TestParseCIDRLenientErrors(t *testing.T) {
+	for _, tc := range []string{
+		"",
+		"10",
+		"10./8",
+		"/8",
+		"10.0.0.0.0/8",
+		"192.168.0.01/24",
+		"192.168.0.0/33",
+		"192.168.0.0/255.0.255.0",
+	} {
+		_, warn, err := cidr2hcmask.ParseCIDRLenient(tc)
+		if err == nil {
+			t.Errorf("%q: error expected", tc)
+		}
+		if warn != nil {
+			t.Errorf("%q: unexpected warning %q", tc, warn)
+		}
+	}
+}
+
+// The errors are reported on the input, not on its expansion.
+func TestParseCIDRLenientParseError(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		offset int
+	}{
+		{"300.1.1.1", 0},
+		{"10.1.1.01", 7},
+		{"10", 2},
+		{"10/33", 3},
+		{"10.1/0x", 5},
+		{"10.300/8", 3},
+		{"10.1/8", 3}, // warning
+	} {
+		_, warn, err := cidr2hcmask.ParseCIDRLenient(tc.in)
+		if err == nil {
+			err = warn
+		}
+		var perr *cidr2hcmask.ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: ParseError expected, got %v", tc.in, err)
+			continue
+		}
+		if perr.Input != tc.in || perr.Offset != tc.offset {
+			t.Errorf("%q: got input %q, offset %d, expected offset %d", tc.in, perr.Input, perr.Offset, tc.offset)
+		}
+	}
+}
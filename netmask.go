@@ -0,0 +1,64 @@
+package cidr2hcmask
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// ErrNonContiguousMask is the error returned by [ParseNetmask] for a netmask (or a
+// wildcard mask) whose bits set are not contiguous, such as 255.0.255.0.
+var ErrNonContiguousMask = errors.New("non-contiguous mask")
+
+// ParseNetmask parses an IPv4 network address written with a dotted netmask, as in
+// router configurations, or with a Cisco wildcard mask (the inverse of the netmask)
+// separated by a space, as in ACLs.
+//
+// Exemple values: 192.168.0.0/255.255.255.0, 192.168.0.0 0.0.0.255.
+//
+// Errors returned (check with [errors.Is]): [ErrSyntax], [ErrNonContiguousMask], [ErrNonZeroBits]
+func ParseNetmask(s string) (IPv4Net, error) {
+	net, err := parseNetmask(s)
+	if err != nil {
+		return IPv4Net{}, err
+	}
+	return net, nil
+}
+
+// parseNetmask is like [ParseNetmask] but also returns the canonical network along
+// with [ErrNonZeroBits].
+func parseNetmask(s string) (IPv4Net, error) {
+	ipStr, maskStr, found := strings.Cut(s, "/")
+	wildcard := false
+	if !found {
+		ipStr, maskStr, found = strings.Cut(s, " ")
+		if !found {
+			return IPv4Net{}, ErrSyntax
+		}
+		maskStr = strings.TrimLeft(maskStr, " ")
+		wildcard = true
+	}
+	ip, err := parseIPv4(ipStr)
+	if err != nil {
+		return IPv4Net{}, err
+	}
+	maskIP, err := parseIPv4(maskStr)
+	if err != nil {
+		return IPv4Net{}, err
+	}
+	mask := ipv4ToUint32(maskIP)
+	if wildcard {
+		mask = ^mask
+	}
+	ones := bits.LeadingZeros32(^mask)
+	if bits.TrailingZeros32(mask) != 32-ones {
+		return IPv4Net{}, fmt.Errorf("%s: %w", s, ErrNonContiguousMask)
+	}
+
+	net := IPv4Net{IP: uint32ToIPv4(ipv4ToUint32(ip) & mask), Bits: ones}
+	if net.IP != ip {
+		return net, fmt.Errorf("%s: %w (%s expected)", s, ErrNonZeroBits, net)
+	}
+	return net, nil
+}
@@ -0,0 +1,54 @@
+package cidr2hcmask_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func TestParseNetmask(t *testing.T) {
+	for _, tc := range [][2]string{
+		{"192.168.0.0/255.255.255.0", "192.168.0.0/24"},
+		{"192.168.0.0 0.0.0.255", "192.168.0.0/24"},
+		{"10.0.0.0/255.0.0.0", "10.0.0.0/8"},
+		{"10.0.0.0  0.255.255.255", "10.0.0.0/8"},
+		{"172.16.0.0/255.240.0.0", "172.16.0.0/12"},
+		{"172.16.0.0 0.15.255.255", "172.16.0.0/12"},
+		{"0.0.0.0/0.0.0.0", "0.0.0.0/0"},
+		{"0.0.0.0 255.255.255.255", "0.0.0.0/0"},
+		{"192.168.1.1/255.255.255.255", "192.168.1.1/32"},
+		{"192.168.1.1 0.0.0.0", "192.168.1.1/32"},
+	} {
+		net, err := cidr2hcmask.ParseNetmask(tc[0])
+		if err != nil {
+			t.Errorf("%q: %v", tc[0], err)
+			continue
+		}
+		if net.String() != tc[1] {
+			t.Errorf("%q: got %q, expected %q", tc[0], net, tc[1])
+		}
+	}
+}
+
+func TestParseNetmaskErrors(t *testing.T) {
+	for _, tc := range []struct {
+		in  string
+		err error
+	}{
+		{"192.168.0.0", cidr2hcmask.ErrSyntax},
+		{"192.168.0.0/24", cidr2hcmask.ErrSyntax},
+		{"192.168.0.0/255.255.255.00", cidr2hcmask.ErrSyntax},
+		{"192.168.0.0/255.0.255.0", cidr2hcmask.ErrNonContiguousMask},
+		{"192.168.0.0/0.0.0.255", cidr2hcmask.ErrNonContiguousMask},
+		{"192.168.0.0 0.0.255.0", cidr2hcmask.ErrNonContiguousMask},
+		{"192.168.0.0 255.255.255.0", cidr2hcmask.ErrNonContiguousMask},
+		{"192.168.0.1/255.255.255.0", cidr2hcmask.ErrNonZeroBits},
+		{"192.168.0.1 0.0.0.255", cidr2hcmask.ErrNonZeroBits},
+	} {
+		_, err := cidr2hcmask.ParseNetmask(tc.in)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%q: %q expected, got %v", tc.in, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package cidr2hcmask
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OctetRange is a range of values of an octet, from First to Last (included).
+type OctetRange struct {
+	First uint8
+	Last  uint8
+}
+
+// OctetRanges represents a set of IPv4 addresses as the cross product of lists of
+// ranges for each octet, like the [nmap target specification]: 10.0-3.*.1.
+//
+// [nmap target specification]: https://nmap.org/book/man-target-specification.html
+type OctetRanges [4][]OctetRange
+
+// String uses the nmap notation, with "*" for the full range of an octet.
+//
+// String implements interface [fmt.Stringer].
+func (o OctetRanges) String() string {
+	var b []byte
+	for i := range o {
+		if i > 0 {
+			b = append(b, '.')
+		}
+		if len(o[i]) == 1 && o[i][0] == (OctetRange{0, 255}) {
+			b = append(b, '*')
+			continue
+		}
+		for j, r := range o[i] {
+			if j > 0 {
+				b = append(b, ',')
+			}
+			b = strconv.AppendUint(b, uint64(r.First), 10)
+			if r.Last != r.First {
+				b = append(b, '-')
+				b = strconv.AppendUint(b, uint64(r.Last), 10)
+			}
+		}
+	}
+	return string(b)
+}
+
+// Size returns the count of addresses.
+func (o OctetRanges) Size() uint64 {
+	size := uint64(1)
+	for i := range o {
+		var n uint64
+		for _, r := range o[i] {
+			n += uint64(r.Last) - uint64(r.First) + 1
+		}
+		size *= n
+	}
+	return size
+}
+
+// ParseOctetRanges parses an IPv4 target in nmap notation: each of the 4 octets is
+// either "*" (0-255) or a comma-separated list of values and ranges. A range may omit
+// its start (0) or its end (255).
+//
+// Exemple values: 10.0-3.*.1, 192.168.1,3,5.10-20, 10.0.0.-100.
+//
+// The ranges of each octet are sorted and merged.
+//
+// Errors returned (check with [errors.Is]): [ErrSyntax]
+func ParseOctetRanges(s string) (OctetRanges, error) {
+	var o OctetRanges
+	parts := strings.SplitN(s, ".", 5)
+	if len(parts) != 4 {
+		return OctetRanges{}, ErrSyntax
+	}
+	for i, part := range parts {
+		if part == "*" {
+			o[i] = []OctetRange{{0, 255}}
+			continue
+		}
+		for _, item := range strings.Split(part, ",") {
+			firstStr, lastStr, isRange := strings.Cut(item, "-")
+			r := OctetRange{0, 255}
+			if !isRange || firstStr != "" {
+				n, err := parseOctet(firstStr)
+				if err != nil {
+					return OctetRanges{}, err
+				}
+				r.First, r.Last = n, n
+			}
+			if isRange {
+				r.Last = 255
+				if lastStr != "" {
+					n, err := parseOctet(lastStr)
+					if err != nil {
+						return OctetRanges{}, err
+					}
+					r.Last = n
+				}
+				if r.Last < r.First {
+					return OctetRanges{}, ErrSyntax
+				}
+			}
+			o[i] = append(o[i], r)
+		}
+		o[i] = mergeOctetRanges(o[i])
+	}
+	return o, nil
+}
+
+func parseOctet(s string) (uint8, error) {
+	if len(s) > 1 && s[0] == '0' { // Disallow leading zero
+		return 0, ErrSyntax
+	}
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, ErrSyntax
+	}
+	return uint8(n), nil
+}
+
+func mergeOctetRanges(ranges []OctetRange) []OctetRange {
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].First < ranges[j].First
+	})
+	merged := ranges[:1]
+	for _, r := range ranges[1:] {
+		last := &merged[len(merged)-1]
+		if int(r.First) <= int(last.Last)+1 {
+			if r.Last > last.Last {
+				last.Last = r.Last
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// Set returns the set of the addresses of o.
+func (o OctetRanges) Set() IPv4Set {
+	var s IPv4Set
+	for _, a := range o[0] {
+		for b := int(a.First); b <= int(a.Last); b++ {
+			for _, c := range o[1] {
+				for d := int(c.First); d <= int(c.Last); d++ {
+					for _, e := range o[2] {
+						for f := int(e.First); f <= int(e.Last); f++ {
+							for _, g := range o[3] {
+								s.AddRange(IPv4Range{
+									First: [4]byte{byte(b), byte(d), byte(f), g.First},
+									Last:  [4]byte{byte(b), byte(d), byte(f), g.Last},
+								})
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return s
+}
+
+func octetRanges2hcmask(o OctetRanges) [4][]string {
+	var masks [4][]string
+	for i := range o {
+		for _, r := range o[i] {
+			masks[i] = append(masks[i], lookupRange(r.First, r.Last)...)
+		}
+	}
+	return masks
+}
+
+// OctetRanges2HCMaskFunc calls cb with each hashcat mask of the set of masks that
+// cover exactly the addresses of o.
+func OctetRanges2HCMaskFunc(o OctetRanges, cb func(mask string)) {
+	expand(octetRanges2hcmask(o), cb)
+}
+
+func OctetRanges2HCMask(o OctetRanges) []string {
+	var masks []string
+	OctetRanges2HCMaskFunc(o, func(mask string) {
+		masks = append(masks, mask)
+	})
+	return masks
+}
+
+func OctetRanges2HCMaskWrite(o OctetRanges, w io.Writer) error {
+	return writeMasks(w, func(cb func(string)) {
+		OctetRanges2HCMaskFunc(o, cb)
+	})
+}
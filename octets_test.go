@@ -0,0 +1,143 @@
+package cidr2hcmask_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func TestParseOctetRanges(t *testing.T) {
+	for _, tc := range [][2]string{
+		{"10.0-3.*.1", "10.0-3.*.1"},
+		{"192.168.1,3,5.10-20", "192.168.1,3,5.10-20"},
+		{"192.168.5,1,3,2.-20", "192.168.1-3,5.0-20"},
+		{"10.0.0.200-", "10.0.0.200-255"},
+		{"10.0.0.-", "10.0.0.*"},
+		{"10.0.0.0-255", "10.0.0.*"},
+		{"10.0.0.1-10,5-20", "10.0.0.1-20"},
+	} {
+		o, err := cidr2hcmask.ParseOctetRanges(tc[0])
+		if err != nil {
+			t.Errorf("%q: %v", tc[0], err)
+			continue
+		}
+		if o.String() != tc[1] {
+			t.Errorf("%q: got %q, expected %q", tc[0], o, tc[1])
+		}
+	}
+
+	for _, tc := range []string{
+		"10.0.0",
+		"10.0.0.0.0",
+		"10.0.0.256",
+		"10.0.0.01",
+		"10.0.0.20-10",
+		"10.0.0.1,",
+		"10.0.0.**",
+		"10.0.0.0/24",
+	} {
+		if _, err := cidr2hcmask.ParseOctetRanges(tc); !errors.Is(err, cidr2hcmask.ErrSyntax) {
+			t.Errorf("%q: ErrSyntax expected, got %v", tc, err)
+		}
+	}
+}
+
+func TestOctetRanges2HCMask(t *testing.T) {
+	for _, tc := range []string{
+		"10.0-3.*.1",
+		"192.168.1,3,5.10-20",
+		"10.0-3.7.1-5",
+		"10.1,3-4.5-9.250-",
+	} {
+		o, err := cidr2hcmask.ParseOctetRanges(tc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := make(map[string]bool)
+		for _, a := range o[0] {
+			for b := a.First; ; b++ {
+				for _, c := range o[1] {
+					for d := c.First; ; d++ {
+						for _, e := range o[2] {
+							for f := e.First; ; f++ {
+								for _, g := range o[3] {
+									for h := g.First; ; h++ {
+										expected[fmt.Sprintf("%d.%d.%d.%d", b, d, f, h)] = false
+										if h == g.Last {
+											break
+										}
+									}
+								}
+								if f == e.Last {
+									break
+								}
+							}
+						}
+						if d == c.Last {
+							break
+						}
+					}
+				}
+				if b == a.Last {
+					break
+				}
+			}
+		}
+		if set := o.Set(); set.Size() != o.Size() {
+			t.Errorf("%s: Set: got %d addresses, expected %d", tc, set.Size(), o.Size())
+		}
+		if uint64(len(expected)) != o.Size() {
+			t.Errorf("%s: Size: got %d, expected %d", tc, o.Size(), len(expected))
+		}
+
+		cidr2hcmask.OctetRanges2HCMaskFunc(o, func(mask string) {
+			t.Log(mask)
+			HCMaskExpand(mask, func(b []byte) {
+				seen, ok := expected[string(b)]
+				if !ok {
+					t.Errorf("%s: unexpected %q", mask, b)
+				} else if seen {
+					t.Errorf("%s: duplicate %q", mask, b)
+				}
+				expected[string(b)] = true
+			})
+		})
+		for ip, seen := range expected {
+			if !seen {
+				t.Errorf("%s: %s not matched", tc, ip)
+			}
+		}
+	}
+}
+
+func TestOctetRanges2HCMaskCIDR(t *testing.T) {
+	o, err := cidr2hcmask.ParseOctetRanges("192.168.1.*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cidr2hcmask.OctetRanges2HCMask(o)
+	expected := cidr2hcmask.CIDR2HCMask(mustParseCIDR("192.168.1.0/24"))
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func ExampleOctetRanges2HCMaskFunc() {
+	o, err := cidr2hcmask.ParseOctetRanges("10.0-3.7.1-5")
+	if err != nil {
+		panic(err)
+	}
+
+	cidr2hcmask.OctetRanges2HCMaskFunc(o, func(mask string) {
+		fmt.Println(mask)
+	})
+
+	// Output:
+	// 01234,012345,123456789,0123,10.?4.7.1
+	// 01234,012345,123456789,0123,10.?4.7.2
+	// 01234,012345,123456789,0123,10.?4.7.3
+	// 01234,012345,123456789,0123,10.?4.7.4
+	// 01234,012345,123456789,0123,10.?4.7.5
+}
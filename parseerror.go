@@ -0,0 +1,176 @@
+package cidr2hcmask
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ParseErrorReason tells why an input was rejected by [ParseCIDR].
+type ParseErrorReason uint8
+
+const (
+	ReasonOctetCount    ParseErrorReason = iota + 1 // not 4 octets
+	ReasonInvalidOctet                              // empty or not decimal
+	ReasonLeadingZero                               // in an octet or in the prefix length
+	ReasonOctetRange                                // octet > 255
+	ReasonMissingPrefix                             // no /bits
+	ReasonInvalidPrefix                             // prefix length empty or not decimal
+	ReasonPrefixRange                               // prefix length > 32
+	ReasonNonZeroBits                               // bits set in the address after the prefix
+)
+
+var reasonTexts = [...]string{
+	ReasonOctetCount:    "4 octets expected",
+	ReasonInvalidOctet:  "invalid octet",
+	ReasonLeadingZero:   "leading zero",
+	ReasonOctetRange:    "octet > 255",
+	ReasonMissingPrefix: "missing /bits",
+	ReasonInvalidPrefix: "invalid prefix length",
+	ReasonPrefixRange:   "prefix length > 32",
+	ReasonNonZeroBits:   "non-zero bits",
+}
+
+// String implements interface [fmt.Stringer].
+func (r ParseErrorReason) String() string {
+	if int(r) < len(reasonTexts) && reasonTexts[r] != "" {
+		return reasonTexts[r]
+	}
+	return "ParseErrorReason(" + strconv.Itoa(int(r)) + ")"
+}
+
+// ParseError is the error returned by [ParseCIDR]. It tells where the input is
+// wrong, why, and how to correct it when the intent is obvious.
+//
+// ParseError matches [ErrNonZeroBits] with [errors.Is] for reason
+// [ReasonNonZeroBits], and [ErrSyntax] for the other reasons.
+type ParseError struct {
+	Input  string
+	Offset int // byte offset in Input of the faulty part
+	Reason ParseErrorReason
+	Fix    string // suggested correction of Input, or empty
+}
+
+// Error uses the format "<input>: non-zero bits (<fix> expected)" for reason
+// [ReasonNonZeroBits], and "<input>: syntax error (<reason>[, <fix> expected])"
+// for the others.
+func (e *ParseError) Error() string {
+	if e.Reason == ReasonNonZeroBits {
+		return e.Input + ": " + ErrNonZeroBits.Error() + " (" + e.Fix + " expected)"
+	}
+	msg := e.Input + ": " + ErrSyntax.Error() + " (" + e.Reason.String()
+	if e.Fix != "" {
+		msg += ", " + e.Fix + " expected"
+	}
+	return msg + ")"
+}
+
+// Is allows to check the error class with [errors.Is].
+func (e *ParseError) Is(target error) bool {
+	if e.Reason == ReasonNonZeroBits {
+		return target == ErrNonZeroBits
+	}
+	return target == ErrSyntax
+}
+
+// parseDecimal parses a decimal number without leading zeros, up to max.
+func parseDecimal(s string, max uint64) (uint64, ParseErrorReason) {
+	if s == "" {
+		return 0, ReasonInvalidOctet
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, ReasonInvalidOctet
+		}
+	}
+	if len(s) > 1 && s[0] == '0' {
+		return 0, ReasonLeadingZero
+	}
+	n, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || n > max {
+		return 0, ReasonOctetRange
+	}
+	return n, 0
+}
+
+// scanCIDR parses s like [ParseCIDR], but without suggesting a fix. For reason
+// [ReasonNonZeroBits], the canonical network is returned along with the error.
+func scanCIDR(s string) (IPv4Net, *ParseError) {
+	ipStr, bitsStr, found := strings.Cut(s, "/")
+
+	var net IPv4Net
+	offset := 0
+	parts := strings.SplitN(ipStr, ".", 5)
+	for i, part := range parts {
+		if i == 4 {
+			return IPv4Net{}, &ParseError{Input: s, Offset: offset - 1, Reason: ReasonOctetCount}
+		}
+		n, reason := parseDecimal(part, 255)
+		if reason != 0 {
+			return IPv4Net{}, &ParseError{Input: s, Offset: offset, Reason: reason}
+		}
+		net.IP[i] = byte(n)
+		offset += len(part) + 1
+	}
+	if len(parts) < 4 {
+		return IPv4Net{}, &ParseError{Input: s, Offset: len(ipStr), Reason: ReasonOctetCount}
+	}
+	if !found {
+		return IPv4Net{}, &ParseError{Input: s, Offset: len(s), Reason: ReasonMissingPrefix}
+	}
+
+	bits, reason := parseDecimal(bitsStr, 32)
+	switch reason {
+	case 0:
+	case ReasonInvalidOctet:
+		return IPv4Net{}, &ParseError{Input: s, Offset: len(ipStr) + 1, Reason: ReasonInvalidPrefix}
+	case ReasonOctetRange:
+		return IPv4Net{}, &ParseError{Input: s, Offset: len(ipStr) + 1, Reason: ReasonPrefixRange}
+	default:
+		return IPv4Net{}, &ParseError{Input: s, Offset: len(ipStr) + 1, Reason: reason}
+	}
+	net.Bits = int(bits)
+
+	canonical := IPv4Net{IP: uint32ToIPv4(ipv4ToUint32(net.IP) & ^uint32(uint64(1)<<(32-bits)-1)), Bits: net.Bits}
+	if canonical.IP != net.IP {
+		offset = 0
+		for i := 0; canonical.IP[i] == net.IP[i]; i++ {
+			offset += len(parts[i]) + 1
+		}
+		return canonical, &ParseError{Input: s, Offset: offset, Reason: ReasonNonZeroBits, Fix: canonical.String()}
+	}
+	return net, nil
+}
+
+// fixCIDR suggests a correction of an input rejected by [scanCIDR], only when the
+// intent is clear: the canonical network for a network with leading zeros or with
+// host bits set. Truncated inputs (10/8, or a port number) get no suggestion:
+// [ParseCIDRLenient] expands them explicitly.
+func fixCIDR(e *ParseError) string {
+	switch e.Reason {
+	case ReasonNonZeroBits:
+		return e.Fix
+	case ReasonLeadingZero:
+	default:
+		return ""
+	}
+	net, err := scanCIDR(stripLeadingZeros(e.Input))
+	if err != nil && err.Reason != ReasonNonZeroBits {
+		return ""
+	}
+	return net.String()
+}
+
+// stripLeadingZeros removes the leading zeros of the numbers of s.
+func stripLeadingZeros(s string) string {
+	isDigit := func(c byte) bool { return c >= '0' && c <= '9' }
+	b := make([]byte, 0, len(s))
+	inNumber := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == '0' && !inNumber && i+1 < len(s) && isDigit(s[i+1]) {
+			continue
+		}
+		b = append(b, s[i])
+		inNumber = isDigit(s[i])
+	}
+	return string(b)
+}
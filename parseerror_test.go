@@ -0,0 +1,54 @@
+package cidr2hcmask_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func TestParseError(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		offset int
+		reason cidr2hcmask.ParseErrorReason
+		fix    string
+		msg    string
+	}{
+		{"1.2.3.4", 7, cidr2hcmask.ReasonMissingPrefix, "", "1.2.3.4: syntax error (missing /bits)"},
+		{"1.2.3.4.5/32", 7, cidr2hcmask.ReasonOctetCount, "", "1.2.3.4.5/32: syntax error (4 octets expected)"},
+		{"10/8", 2, cidr2hcmask.ReasonOctetCount, "", "10/8: syntax error (4 octets expected)"},
+		{"80/32", 2, cidr2hcmask.ReasonOctetCount, "", "80/32: syntax error (4 octets expected)"},
+		{"1.2.3./32", 6, cidr2hcmask.ReasonInvalidOctet, "", "1.2.3./32: syntax error (invalid octet)"},
+		{"1.a.3.4/32", 2, cidr2hcmask.ReasonInvalidOctet, "", "1.a.3.4/32: syntax error (invalid octet)"},
+		{"1.2.3.256/0", 6, cidr2hcmask.ReasonOctetRange, "", "1.2.3.256/0: syntax error (octet > 255)"},
+		{"1111111111111111111/32", 0, cidr2hcmask.ReasonOctetRange, "", "1111111111111111111/32: syntax error (octet > 255)"},
+		{"1.2.3.04/32", 6, cidr2hcmask.ReasonLeadingZero, "1.2.3.4/32", "1.2.3.04/32: syntax error (leading zero, 1.2.3.4/32 expected)"},
+		{"010.000.01.0/024", 0, cidr2hcmask.ReasonLeadingZero, "10.0.1.0/24", "010.000.01.0/024: syntax error (leading zero, 10.0.1.0/24 expected)"},
+		{"10.0.1.01/24", 7, cidr2hcmask.ReasonLeadingZero, "10.0.1.0/24", "10.0.1.01/24: syntax error (leading zero, 10.0.1.0/24 expected)"},
+		{"1.2.3.4/", 8, cidr2hcmask.ReasonInvalidPrefix, "", "1.2.3.4/: syntax error (invalid prefix length)"},
+		{"1.2.3.4/a", 8, cidr2hcmask.ReasonInvalidPrefix, "", "1.2.3.4/a: syntax error (invalid prefix length)"},
+		{"1.2.3.4/33", 8, cidr2hcmask.ReasonPrefixRange, "", "1.2.3.4/33: syntax error (prefix length > 32)"},
+		{"1.2.3.4/032", 8, cidr2hcmask.ReasonLeadingZero, "1.2.3.4/32", "1.2.3.4/032: syntax error (leading zero, 1.2.3.4/32 expected)"},
+		{"192.168.0.1/16", 10, cidr2hcmask.ReasonNonZeroBits, "192.168.0.0/16", "192.168.0.1/16: non-zero bits (192.168.0.0/16 expected)"},
+		{"192.168.7.0/22", 8, cidr2hcmask.ReasonNonZeroBits, "192.168.4.0/22", "192.168.7.0/22: non-zero bits (192.168.4.0/22 expected)"},
+		{"128.0.0.0/0", 0, cidr2hcmask.ReasonNonZeroBits, "0.0.0.0/0", "128.0.0.0/0: non-zero bits (0.0.0.0/0 expected)"},
+	} {
+		_, err := cidr2hcmask.ParseCIDR(tc.in)
+		var perr *cidr2hcmask.ParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("%q: ParseError expected, got %v", tc.in, err)
+			continue
+		}
+		if perr.Input != tc.in || perr.Offset != tc.offset || perr.Reason != tc.reason || perr.Fix != tc.fix {
+			t.Errorf("%q: got %+v", tc.in, *perr)
+		}
+		if err.Error() != tc.msg {
+			t.Errorf("%q: got %q, expected %q", tc.in, err, tc.msg)
+		}
+		isNonZeroBits := tc.reason == cidr2hcmask.ReasonNonZeroBits
+		if errors.Is(err, cidr2hcmask.ErrNonZeroBits) != isNonZeroBits || errors.Is(err, cidr2hcmask.ErrSyntax) == isNonZeroBits {
+			t.Errorf("%q: wrong class: %v", tc.in, err)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package cidr2hcmask
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// specialRegistryCSV is the IANA IPv4 Special-Purpose Address Registry, from
+// https://www.iana.org/assignments/iana-ipv4-special-registry/iana-ipv4-special-registry-1.csv
+// (footnote markers removed).
+//
+//go:embed iana-ipv4-special-registry.csv
+var specialRegistryCSV string
+
+// SpecialBlock is an entry of the [IANA IPv4 Special-Purpose Address Registry].
+//
+// The properties of a deprecated entry (terminated allocation) are all false.
+//
+// [IANA IPv4 Special-Purpose Address Registry]: https://www.iana.org/assignments/iana-ipv4-special-registry/
+type SpecialBlock struct {
+	Nets               []IPv4Net
+	Name               string
+	RFC                string
+	Deprecated         bool
+	Source             bool
+	Destination        bool
+	Forwardable        bool
+	GloballyReachable  bool
+	ReservedByProtocol bool
+}
+
+var specialRegistry struct {
+	once   sync.Once
+	blocks []SpecialBlock
+}
+
+// SpecialRegistry returns the entries of the IANA IPv4 Special-Purpose Address
+// Registry embedded in the package, in the order of the registry.
+func SpecialRegistry() []SpecialBlock {
+	specialRegistry.once.Do(func() {
+		blocks, err := parseSpecialRegistry(specialRegistryCSV)
+		if err != nil {
+			panic("iana-ipv4-special-registry.csv: " + err.Error())
+		}
+		specialRegistry.blocks = blocks
+	})
+	return append([]SpecialBlock(nil), specialRegistry.blocks...)
+}
+
+func parseSpecialRegistry(data string) ([]SpecialBlock, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]SpecialBlock, 0, len(records)-1)
+	for _, rec := range records[1:] {
+		if len(rec) != 10 {
+			return nil, fmt.Errorf("%q: 10 fields expected", rec)
+		}
+		b := SpecialBlock{
+			Name:               rec[1],
+			RFC:                rec[2],
+			Deprecated:         rec[4] != "N/A",
+			Source:             rec[5] == "True",
+			Destination:        rec[6] == "True",
+			Forwardable:        rec[7] == "True",
+			GloballyReachable:  rec[8] == "True",
+			ReservedByProtocol: rec[9] == "True",
+		}
+		for _, s := range strings.Split(rec[0], ",") {
+			net, err := ParseCIDR(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("%q: %w", s, err)
+			}
+			b.Nets = append(b.Nets, net)
+		}
+		blocks = append(blocks, b)
+	}
+	return blocks, nil
+}
+
+// specialSet returns the addresses of the registry entries selected by match.
+func specialSet(match func(b *SpecialBlock) bool) IPv4Set {
+	var set IPv4Set
+	for _, b := range SpecialRegistry() {
+		if match(&b) {
+			set = set.Union(NewIPv4SetFromNets(b.Nets...))
+		}
+	}
+	return set
+}
+
+// notGloballyReachable returns the addresses that the registry marks as not
+// globally reachable. The most specific entry wins: 192.0.0.9/32 is reachable
+// although 192.0.0.0/24 is not. Deprecated entries are back to the general pool.
+func notGloballyReachable() IPv4Set {
+	blocks := SpecialRegistry()
+	sort.SliceStable(blocks, func(i, j int) bool {
+		return blocks[i].Nets[0].Bits < blocks[j].Nets[0].Bits
+	})
+	var set IPv4Set
+	for _, b := range blocks {
+		if b.GloballyReachable || b.Deprecated {
+			set = set.Difference(NewIPv4SetFromNets(b.Nets...))
+		} else {
+			set = set.Union(NewIPv4SetFromNets(b.Nets...))
+		}
+	}
+	return set
+}
+
+// multicast is 224.0.0.0/4 (RFC 5771), which is not part of the special-purpose
+// registry.
+var multicast = IPv4Net{IP: [4]byte{224, 0, 0, 0}, Bits: 4}
+
+func specialName(name string) func(b *SpecialBlock) bool {
+	return func(b *SpecialBlock) bool { return b.Name == name }
+}
+
+// presets are the named sets of addresses of [Preset].
+var presets = []struct {
+	names []string
+	set   func() IPv4Set
+}{
+	{[]string{"private", "rfc1918"}, func() IPv4Set { return specialSet(specialName("Private-Use")) }},
+	{[]string{"cgnat", "shared", "rfc6598"}, func() IPv4Set { return specialSet(specialName("Shared Address Space")) }},
+	{[]string{"loopback"}, func() IPv4Set { return specialSet(specialName("Loopback")) }},
+	{[]string{"link-local"}, func() IPv4Set { return specialSet(specialName("Link Local")) }},
+	{[]string{"documentation", "test-net"}, func() IPv4Set {
+		return specialSet(func(b *SpecialBlock) bool { return strings.HasPrefix(b.Name, "Documentation ") })
+	}},
+	{[]string{"benchmarking"}, func() IPv4Set { return specialSet(specialName("Benchmarking")) }},
+	{[]string{"ietf"}, func() IPv4Set { return specialSet(specialName("IETF Protocol Assignments")) }},
+	{[]string{"this-network"}, func() IPv4Set { return specialSet(specialName(`"This network"`)) }},
+	{[]string{"broadcast"}, func() IPv4Set { return specialSet(specialName("Limited Broadcast")) }},
+	{[]string{"reserved"}, func() IPv4Set { return specialSet(specialName("Reserved")) }},
+	{[]string{"multicast"}, func() IPv4Set { return NewIPv4SetFromNets(multicast) }},
+	{[]string{"special"}, func() IPv4Set { return specialSet(func(*SpecialBlock) bool { return true }) }},
+	{[]string{"bogon"}, func() IPv4Set { return notGloballyReachable().Union(NewIPv4SetFromNets(multicast)) }},
+}
+
+// ErrUnknownPreset is the error returned by [Preset] and [ParsePresets] for an
+// unknown preset name.
+var ErrUnknownPreset = errors.New("unknown preset")
+
+// PresetNames returns the names of the presets of [Preset], including aliases.
+func PresetNames() []string {
+	var names []string
+	for _, p := range presets {
+		names = append(names, p.names...)
+	}
+	return names
+}
+
+// Preset returns the set of addresses of a named block of special-purpose addresses.
+// The name may be prefixed with "@".
+//
+// Presets (aliases in parentheses):
+//   - private (rfc1918): 10.0.0.0/8, 172.16.0.0/12, 192.168.0.0/16
+//   - cgnat (shared, rfc6598): 100.64.0.0/10
+//   - loopback: 127.0.0.0/8
+//   - link-local: 169.254.0.0/16
+//   - documentation (test-net): 192.0.2.0/24, 198.51.100.0/24, 203.0.113.0/24
+//   - benchmarking: 198.18.0.0/15
+//   - ietf: 192.0.0.0/24
+//   - this-network: 0.0.0.0/8
+//   - broadcast: 255.255.255.255/32
+//   - reserved: 240.0.0.0/4
+//   - multicast: 224.0.0.0/4
+//   - special: all the entries of the special-purpose registry (see [SpecialRegistry])
+//   - bogon: the special-purpose addresses that are not globally reachable, and multicast
+//
+// Errors returned (check with [errors.Is]): [ErrUnknownPreset]
+func Preset(name string) (IPv4Set, error) {
+	n := strings.ToLower(strings.TrimPrefix(name, "@"))
+	for _, p := range presets {
+		for _, pn := range p.names {
+			if pn == n {
+				return p.set(), nil
+			}
+		}
+	}
+	return IPv4Set{}, fmt.Errorf("%s: %w", name, ErrUnknownPreset)
+}
+
+// ParsePresets parses a comma-separated list of presets names, each prefixed with
+// "@", and returns the union of their sets of addresses. See [Preset].
+//
+// Exemple value: @private,@cgnat.
+//
+// Errors returned (check with [errors.Is]): [ErrSyntax], [ErrUnknownPreset]
+func ParsePresets(s string) (IPv4Set, error) {
+	var set IPv4Set
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if !strings.HasPrefix(name, "@") {
+			return IPv4Set{}, fmt.Errorf("%s: %w (@<preset> expected)", name, ErrSyntax)
+		}
+		p, err := Preset(name)
+		if err != nil {
+			return IPv4Set{}, err
+		}
+		set = set.Union(p)
+	}
+	return set, nil
+}
+
+// Public returns the globally reachable unicast addresses of s: the addresses of
+// s without the ones of the bogon preset (see [Preset]).
+func (s IPv4Set) Public() IPv4Set {
+	bogon, _ := Preset("bogon")
+	return s.Difference(bogon)
+}
@@ -0,0 +1,126 @@
+package cidr2hcmask_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func TestSpecialRegistry(t *testing.T) {
+	blocks := cidr2hcmask.SpecialRegistry()
+	if len(blocks) < 20 {
+		t.Fatalf("%d entries", len(blocks))
+	}
+	for _, b := range blocks {
+		if len(b.Nets) == 0 || b.Name == "" {
+			t.Errorf("%+v", b)
+		}
+		t.Logf("%v %q reachable=%t", b.Nets, b.Name, b.GloballyReachable)
+	}
+	blocks[0].Name = "changed"
+	if cidr2hcmask.SpecialRegistry()[0].Name == "changed" {
+		t.Error("SpecialRegistry must return a copy")
+	}
+}
+
+func TestPreset(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		expected string
+	}{
+		{"@private", "10.0.0.0-10.255.255.255,172.16.0.0-172.31.255.255,192.168.0.0-192.168.255.255"},
+		{"rfc1918", "10.0.0.0-10.255.255.255,172.16.0.0-172.31.255.255,192.168.0.0-192.168.255.255"},
+		{"@cgnat", "100.64.0.0-100.127.255.255"},
+		{"@loopback", "127.0.0.0-127.255.255.255"},
+		{"@link-local", "169.254.0.0-169.254.255.255"},
+		{"@documentation", "192.0.2.0-192.0.2.255,198.51.100.0-198.51.100.255,203.0.113.0-203.0.113.255"},
+		{"@benchmarking", "198.18.0.0-198.19.255.255"},
+		{"@this-network", "0.0.0.0-0.255.255.255"},
+		{"@broadcast", "255.255.255.255"},
+		{"@multicast", "224.0.0.0-239.255.255.255"},
+		{"@Reserved", "240.0.0.0-255.255.255.255"},
+		{"@bogon", "0.0.0.0-0.255.255.255,10.0.0.0-10.255.255.255,100.64.0.0-100.127.255.255," +
+			"127.0.0.0-127.255.255.255,169.254.0.0-169.254.255.255,172.16.0.0-172.31.255.255," +
+			"192.0.0.0-192.0.0.8,192.0.0.11-192.0.0.255,192.0.2.0-192.0.2.255,192.168.0.0-192.168.255.255," +
+			"198.18.0.0-198.19.255.255,198.51.100.0-198.51.100.255,203.0.113.0-203.0.113.255," +
+			"224.0.0.0-255.255.255.255"},
+	} {
+		set, err := cidr2hcmask.Preset(tc.name)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got := set.String(); got != tc.expected {
+			t.Errorf("%s: got %s, expected %s", tc.name, got, tc.expected)
+		}
+	}
+}
+
+func TestPresetNames(t *testing.T) {
+	for _, name := range cidr2hcmask.PresetNames() {
+		set, err := cidr2hcmask.Preset(name)
+		if err != nil || set.IsEmpty() {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestParsePresets(t *testing.T) {
+	set, err := cidr2hcmask.ParsePresets("@private, @cgnat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	const expected = "10.0.0.0-10.255.255.255,100.64.0.0-100.127.255.255,172.16.0.0-172.31.255.255,192.168.0.0-192.168.255.255"
+	if got := set.String(); got != expected {
+		t.Errorf("got %s, expected %s", got, expected)
+	}
+
+	for _, tc := range []struct {
+		in  string
+		err error
+	}{
+		{"@private,cgnat", cidr2hcmask.ErrSyntax},
+		{"@private,", cidr2hcmask.ErrSyntax},
+		{"@public", cidr2hcmask.ErrUnknownPreset},
+	} {
+		if _, err := cidr2hcmask.ParsePresets(tc.in); !errors.Is(err, tc.err) {
+			t.Errorf("%q: got %v, expected %v", tc.in, err, tc.err)
+		} else {
+			t.Log(err)
+		}
+	}
+}
+
+func TestIPv4SetPublic(t *testing.T) {
+	all := cidr2hcmask.NewIPv4SetFromNets(cidr2hcmask.IPv4Net{})
+	public := all.Public()
+	for _, tc := range []struct {
+		ip     [4]byte
+		public bool
+	}{
+		{[4]byte{0, 1, 2, 3}, false},
+		{[4]byte{1, 1, 1, 1}, true},
+		{[4]byte{10, 1, 2, 3}, false},
+		{[4]byte{100, 64, 0, 1}, false},
+		{[4]byte{100, 128, 0, 1}, true},
+		{[4]byte{192, 0, 0, 9}, true},
+		{[4]byte{192, 0, 0, 170}, false},
+		{[4]byte{192, 88, 99, 1}, true},
+		{[4]byte{192, 168, 1, 1}, false},
+		{[4]byte{223, 255, 255, 255}, true},
+		{[4]byte{224, 0, 0, 1}, false},
+		{[4]byte{255, 255, 255, 255}, false},
+	} {
+		if got := public.Contains(tc.ip); got != tc.public {
+			t.Errorf("%v: got %t, expected %t", tc.ip, got, tc.public)
+		}
+	}
+
+	t.Logf("0.0.0.0/0: %d masks", len(cidr2hcmask.Set2HCMask(public)))
+
+	for _, s := range []string{"192.0.0.0/16", "198.16.0.0/14"} {
+		set := cidr2hcmask.NewIPv4SetFromNets(mustParseCIDR(s)).Public()
+		t.Logf("%s: %d masks", s, checkSetExpand(t, set))
+	}
+}
@@ -0,0 +1,219 @@
+package cidr2hcmask
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// IPv4Range represents a block of consecutive IPv4 addresses, from First to Last
+// (included). Unlike [IPv4Net] the bounds don't have to be aligned on a CIDR boundary.
+type IPv4Range struct {
+	First [4]byte
+	Last  [4]byte
+}
+
+// String uses the format <first ip>-<last ip>.
+//
+// String implements interface [fmt.Stringer].
+func (r IPv4Range) String() string {
+	return fmt.Sprintf("%d.%d.%d.%d-%d.%d.%d.%d",
+		r.First[0], r.First[1], r.First[2], r.First[3],
+		r.Last[0], r.Last[1], r.Last[2], r.Last[3])
+}
+
+// ParseRange parses a range of IPv4 addresses in the format <first ip>-<last ip>.
+//
+// Exemple value: 10.0.0.200-10.0.1.50.
+//
+// Errors returned (check with [errors.Is]): [ErrSyntax]
+func ParseRange(s string) (IPv4Range, error) {
+	firstStr, lastStr, found := strings.Cut(s, "-")
+	if !found {
+		return IPv4Range{}, ErrSyntax
+	}
+	var r IPv4Range
+	var err error
+	if r.First, err = parseIPv4(firstStr); err != nil {
+		return IPv4Range{}, err
+	}
+	if r.Last, err = parseIPv4(lastStr); err != nil {
+		return IPv4Range{}, err
+	}
+	if ipv4ToUint32(r.First) > ipv4ToUint32(r.Last) {
+		return IPv4Range{}, fmt.Errorf("%s: %w (reversed bounds)", s, ErrSyntax)
+	}
+	return r, nil
+}
+
+// parseIPv4 parses an IPv4 address in dotted decimal notation, without leading zeros.
+func parseIPv4(s string) (ip [4]byte, err error) {
+	if len(s) > 3+1+3+1+3+1+3 {
+		return ip, ErrSyntax
+	}
+	parts := strings.SplitN(s, ".", 5)
+	if len(parts) != 4 {
+		return ip, ErrSyntax
+	}
+	for i, part := range parts {
+		if len(part) > 1 && part[0] == '0' { // Disallow leading zero
+			return ip, ErrSyntax
+		}
+		n, err := strconv.ParseUint(part, 10, 8)
+		if err != nil {
+			return ip, ErrSyntax
+		}
+		ip[i] = byte(n)
+	}
+	return ip, nil
+}
+
+func ipv4ToUint32(ip [4]byte) uint32 {
+	return binary.BigEndian.Uint32(ip[:])
+}
+
+func uint32ToIPv4(n uint32) (ip [4]byte) {
+	binary.BigEndian.PutUint32(ip[:], n)
+	return
+}
+
+// span is a range of IPv4 addresses (or of the low bits of IPv4 addresses) as integers.
+type span struct {
+	Lo, Hi uint32
+}
+
+// term is a node of the factored form of a set of addresses: the addresses
+// matching one of masks for the octet, followed by one of the next terms for
+// the next octets.
+type term struct {
+	masks []string
+	next  []term
+}
+
+// factor groups the values of the octet at depth that share the same set of
+// addresses for the next octets.
+//
+// spans must be sorted, disjoint and relative to the block of the depth.
+func factor(spans []span, depth int) []term {
+	if depth == 3 {
+		var masks []string
+		for _, s := range spans {
+			masks = append(masks, lookupRange(uint8(s.Lo), uint8(s.Hi))...)
+		}
+		return []term{{masks: masks}}
+	}
+
+	shift := 8 * (3 - depth)
+	blockMask := uint32(1)<<shift - 1
+	full := []span{{0, blockMask}}
+
+	type group struct {
+		octets []span
+		sub    []span
+	}
+	var groups []*group
+	groupsByKey := make(map[string]*group)
+	addGroup := func(first, last uint32, sub []span) {
+		key := make([]byte, 0, 8*len(sub))
+		for _, s := range sub {
+			key = binary.BigEndian.AppendUint32(key, s.Lo)
+			key = binary.BigEndian.AppendUint32(key, s.Hi)
+		}
+		g := groupsByKey[string(key)]
+		if g == nil {
+			g = &group{sub: sub}
+			groupsByKey[string(key)] = g
+			groups = append(groups, g)
+		}
+		if n := len(g.octets); n > 0 && g.octets[n-1].Hi+1 == first {
+			g.octets[n-1].Hi = last
+		} else {
+			g.octets = append(g.octets, span{first, last})
+		}
+	}
+
+	spans = append([]span(nil), spans...)
+	for i := 0; i < len(spans); {
+		o := spans[i].Lo >> shift
+		base := o << shift
+		if spans[i].Lo == base && spans[i].Hi-base >= blockMask { // Run of full octets
+			last := spans[i].Hi >> shift
+			if spans[i].Hi&blockMask != blockMask {
+				last--
+				spans[i].Lo = (last + 1) << shift
+			} else {
+				i++
+			}
+			addGroup(o, last, full)
+			continue
+		}
+
+		var sub []span
+		for i < len(spans) && spans[i].Lo>>shift == o {
+			if spans[i].Hi>>shift != o {
+				sub = append(sub, span{spans[i].Lo - base, blockMask})
+				spans[i].Lo = (o + 1) << shift
+				break
+			}
+			sub = append(sub, span{spans[i].Lo - base, spans[i].Hi - base})
+			i++
+		}
+		addGroup(o, o, sub)
+	}
+
+	terms := make([]term, 0, len(groups))
+	for _, g := range groups {
+		var t term
+		for _, o := range g.octets {
+			t.masks = append(t.masks, lookupRange(uint8(o.Lo), uint8(o.Hi))...)
+		}
+		t.next = factor(g.sub, depth+1)
+		terms = append(terms, t)
+	}
+	return terms
+}
+
+// spans2hcmask calls cb with the per-octet masks that cover exactly once the
+// addresses of spans (sorted and disjoint).
+func spans2hcmask(spans []span, cb func(ipmask [4][]string)) {
+	if len(spans) == 0 {
+		return
+	}
+	var ipmask [4][]string
+	var walk func(terms []term, depth int)
+	walk = func(terms []term, depth int) {
+		for _, t := range terms {
+			ipmask[depth] = t.masks
+			if depth == 3 {
+				cb(ipmask)
+			} else {
+				walk(t.next, depth+1)
+			}
+		}
+	}
+	walk(factor(spans, 0), 0)
+}
+
+// Range2HCMaskFunc calls cb with each hashcat mask of the set of masks that cover
+// exactly the addresses of r.
+func Range2HCMaskFunc(r IPv4Range, cb func(mask string)) {
+	spans2hcmask([]span{{ipv4ToUint32(r.First), ipv4ToUint32(r.Last)}}, func(ipmask [4][]string) {
+		expand(ipmask, cb)
+	})
+}
+
+func Range2HCMask(r IPv4Range) []string {
+	var masks []string
+	Range2HCMaskFunc(r, func(mask string) {
+		masks = append(masks, mask)
+	})
+	return masks
+}
+
+func Range2HCMaskWrite(r IPv4Range, w io.Writer) error {
+	return writeMasks(w, func(cb func(string)) {
+		Range2HCMaskFunc(r, cb)
+	})
+}
@@ -0,0 +1,134 @@
+package cidr2hcmask_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func TestParseRange(t *testing.T) {
+	r, err := cidr2hcmask.ParseRange("10.0.0.200-10.0.1.50")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != "10.0.0.200-10.0.1.50" {
+		t.Errorf("got %q", r)
+	}
+
+	for _, tc := range []string{
+		"10.0.0.1",
+		"10.0.0.1-",
+		"-10.0.0.1",
+		"10.0.0.01-10.0.0.2",
+		"10.0.0.1-10.0.0.256",
+		"10.0.0.2-10.0.0.1",
+		"10.0.0.1-10.0.0.2-10.0.0.3",
+	} {
+		if _, err := cidr2hcmask.ParseRange(tc); !errors.Is(err, cidr2hcmask.ErrSyntax) {
+			t.Errorf("%q: ErrSyntax expected, got %v", tc, err)
+		}
+	}
+}
+
+// checkRangeExpand checks that the masks for r match exactly the addresses of r.
+func checkRangeExpand(t *testing.T, r cidr2hcmask.IPv4Range) (masksCount int) {
+	t.Helper()
+	first := binary.BigEndian.Uint32(r.First[:])
+	last := binary.BigEndian.Uint32(r.Last[:])
+	found := make([]bool, last-first+1)
+	cidr2hcmask.Range2HCMaskFunc(r, func(mask string) {
+		masksCount++
+		HCMaskExpand(mask, func(b []byte) {
+			ip, err := netip.ParseAddr(string(b))
+			if err != nil || !ip.Is4() {
+				t.Errorf("%s: %q: invalid IP", mask, b)
+				return
+			}
+			ip4 := ip.As4()
+			n := binary.BigEndian.Uint32(ip4[:])
+			if n < first || n > last {
+				t.Errorf("%s: %q: out of range %s", mask, b, r)
+			} else if found[n-first] {
+				t.Errorf("%s: %q: duplicate", mask, b)
+			} else {
+				found[n-first] = true
+			}
+		})
+	})
+	for i := range found {
+		if !found[i] {
+			var ip [4]byte
+			binary.BigEndian.PutUint32(ip[:], first+uint32(i))
+			t.Errorf("%s: %v not matched", r, netip.AddrFrom4(ip))
+		}
+	}
+	return
+}
+
+func TestRange2HCMaskByte(t *testing.T) {
+	for s := 0; s < 256; s++ {
+		for e := s; e < 256; e++ {
+			checkRangeExpand(t, cidr2hcmask.IPv4Range{
+				First: [4]byte{10, 0, 0, byte(s)},
+				Last:  [4]byte{10, 0, 0, byte(e)},
+			})
+		}
+	}
+}
+
+func TestRange2HCMask(t *testing.T) {
+	for _, tc := range []string{
+		"10.0.0.200-10.0.1.50",
+		"10.0.255.250-10.1.0.5",
+		"10.0.0.0-10.0.5.255",
+		"10.0.0.1-10.0.3.254",
+		"192.168.0.0-192.168.255.255",
+		"0.0.0.0-0.0.0.0",
+		"255.255.255.0-255.255.255.255",
+	} {
+		r, err := cidr2hcmask.ParseRange(tc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := checkRangeExpand(t, r)
+		t.Logf("%s: %d masks", r, n)
+	}
+}
+
+func TestRange2HCMaskCIDR(t *testing.T) {
+	// A range aligned on a CIDR block gives the same masks as the CIDR
+	for _, cidr := range []string{"192.168.0.0/24", "10.0.0.0/22", "172.16.0.0/12", "192.168.1.16/28"} {
+		net, _ := cidr2hcmask.ParseCIDR(cidr)
+		p := netip.MustParsePrefix(cidr)
+		var r cidr2hcmask.IPv4Range
+		r.First = p.Addr().As4()
+		binary.BigEndian.PutUint32(r.Last[:], binary.BigEndian.Uint32(r.First[:])|(1<<(32-p.Bits())-1))
+		expected := cidr2hcmask.CIDR2HCMask(net)
+		got := cidr2hcmask.Range2HCMask(r)
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("%s: got %q, expected %q", cidr, got, expected)
+		}
+	}
+}
+
+func ExampleRange2HCMaskFunc() {
+	r, err := cidr2hcmask.ParseRange("10.0.0.200-10.0.1.50")
+	if err != nil {
+		panic(err)
+	}
+
+	cidr2hcmask.Range2HCMaskFunc(r, func(mask string) {
+		fmt.Println(mask)
+	})
+
+	// Output:
+	// 01234,012345,123456789,10.0.0.2?1?d
+	// 01234,012345,123456789,10.0.0.25?2
+	// 01234,012345,123456789,10.0.1.?d
+	// 01234,012345,123456789,1234,10.0.1.?4?d
+	// 01234,012345,123456789,10.0.1.50
+}
@@ -0,0 +1,89 @@
+package cidr2hcmask
+
+import (
+	"io"
+	"strings"
+)
+
+// regexpNothing is a regular expression that matches nothing.
+const regexpNothing = `^[^\s\S]\z`
+
+// writeRegexpOctet writes the alternation of the masks of an octet.
+func writeRegexpOctet(b *strings.Builder, masks []string) {
+	positions := make([][]string, len(masks))
+	for i, mask := range masks {
+		positions[i] = johnPositions(mask)
+	}
+	positions = mergeJohnPositions(positions)
+	if len(positions) > 1 {
+		b.WriteString("(?:")
+	}
+	for i, p := range positions {
+		if i > 0 {
+			b.WriteByte('|')
+		}
+		for _, digits := range p {
+			if len(digits) == 1 {
+				b.WriteString(digits)
+			} else {
+				b.WriteString(johnCharset(digits))
+			}
+		}
+	}
+	if len(positions) > 1 {
+		b.WriteByte(')')
+	}
+}
+
+// writeRegexpTerms writes the alternation of the factored form of a set of
+// addresses (see [factor]), from the octet at depth.
+func writeRegexpTerms(b *strings.Builder, terms []term, depth int) {
+	if len(terms) > 1 {
+		b.WriteString("(?:")
+	}
+	for i, t := range terms {
+		if i > 0 {
+			b.WriteByte('|')
+		}
+		writeRegexpOctet(b, t.masks)
+		if depth < 3 {
+			b.WriteString(`\.`)
+			writeRegexpTerms(b, t.next, depth+1)
+		}
+	}
+	if len(terms) > 1 {
+		b.WriteByte(')')
+	}
+}
+
+// Set2Regexp returns an anchored regular expression that matches exactly the
+// addresses of s in dotted decimal notation without leading zeros. The syntax is
+// compatible with both RE2 (package [regexp]) and PCRE. The end is anchored with
+// \z, not $ which also matches before a trailing newline in PCRE (Python supports
+// \z since 3.14; use \Z for older versions).
+//
+// The octets values are decomposed in ranges of digits like for the hashcat masks
+// (see [Set2HCMaskFunc]).
+func Set2Regexp(s IPv4Set) string {
+	if len(s.spans) == 0 {
+		return regexpNothing
+	}
+	var b strings.Builder
+	b.WriteByte('^')
+	writeRegexpTerms(&b, factor(s.spans, 0), 0)
+	b.WriteString(`\z`)
+	return b.String()
+}
+
+// CIDR2Regexp returns an anchored regular expression that matches exactly the
+// addresses of net. See [Set2Regexp].
+//
+// Exemple: 192.168.1.0/28 gives ^192\.168\.1\.(?:[0-9]|1[0-5])\z.
+func CIDR2Regexp(net IPv4Net) string {
+	return Set2Regexp(NewIPv4SetFromNets(net))
+}
+
+func Set2RegexpWrite(s IPv4Set, w io.Writer) error {
+	_, err := io.WriteString(w, Set2Regexp(s)+"\n")
+	return err
+}
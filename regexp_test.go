@@ -0,0 +1,90 @@
+package cidr2hcmask_test
+
+import (
+	"fmt"
+	"math/rand"
+	"os/exec"
+	"regexp"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func ExampleCIDR2Regexp() {
+	net, err := cidr2hcmask.ParseCIDR("192.168.1.0/28")
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(cidr2hcmask.CIDR2Regexp(net))
+
+	// Output:
+	// ^192\.168\.1\.(?:[0-9]|1[0-5])\z
+}
+
+func TestCIDR2Regexp(t *testing.T) {
+	for _, tc := range []struct {
+		cidr     string
+		expected string
+	}{
+		{"10.1.2.3/32", `^10\.1\.2\.3\z`},
+		{"10.1.2.4/30", `^10\.1\.2\.[4-7]\z`},
+		{"10.1.2.0/25", `^10\.1\.2\.(?:[0-9]|[1-9][0-9]|1[01][0-9]|12[0-7])\z`},
+	} {
+		if got := cidr2hcmask.CIDR2Regexp(mustParseCIDR(tc.cidr)); got != tc.expected {
+			t.Errorf("%s: got %s, expected %s", tc.cidr, got, tc.expected)
+		}
+	}
+
+	re := regexp.MustCompile(cidr2hcmask.CIDR2Regexp(mustParseCIDR("0.0.0.0/0")))
+	for _, s := range []string{"0.0.0.0", "255.255.255.255", "192.168.1.1", "10.0.100.249"} {
+		if !re.MatchString(s) {
+			t.Errorf("0.0.0.0/0: %s: no match", s)
+		}
+	}
+	for _, s := range []string{"", "0.0.0", "256.0.0.0", "0.0.0.00", "01.0.0.0", "1.2.3.4.5", " 1.2.3.4", "1.2.3.4\n", "1.2.3.a"} {
+		if re.MatchString(s) {
+			t.Errorf("0.0.0.0/0: %q: unexpected match", s)
+		}
+	}
+}
+
+// PCRE: $ would match before a trailing newline
+func TestCIDR2RegexpPerl(t *testing.T) {
+	perl, err := exec.LookPath("perl")
+	if err != nil {
+		t.Skip("perl not found")
+	}
+	re := cidr2hcmask.CIDR2Regexp(mustParseCIDR("10.1.2.0/24"))
+	out, err := exec.Command(perl, "-e", `print join(",", map { /`+re+`/ ? 1 : 0 } @ARGV)`, "10.1.2.3", "10.1.2.3\n", "10.1.3.3").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(out); got != "1,0,0" {
+		t.Errorf("got %s, expected 1,0,0", got)
+	}
+}
+
+func TestSet2Regexp(t *testing.T) {
+	if re := regexp.MustCompile(cidr2hcmask.Set2Regexp(cidr2hcmask.IPv4Set{})); re.MatchString("") || re.MatchString("10.0.0.0") {
+		t.Error("empty set: unexpected match")
+	}
+
+	rnd := rand.New(rand.NewSource(4))
+	for i := 0; i < 50; i++ {
+		set, bitmap := randomSet(rnd)
+		re := regexp.MustCompile(cidr2hcmask.Set2Regexp(set))
+		// Check the addresses around the bitmap
+		for n := -256; n < len(bitmap)+256; n++ {
+			ip := [4]byte{10, 0, byte(n >> 8), byte(n)}
+			if n < 0 {
+				ip = [4]byte{9, 255, 255, byte(n)}
+			}
+			s := fmt.Sprintf("%d.%d.%d.%d", ip[0], ip[1], ip[2], ip[3])
+			expected := n >= 0 && n < len(bitmap) && bitmap[n]
+			if re.MatchString(s) != expected {
+				t.Fatalf("%s: %s: got %t, expected %t", re, s, !expected, expected)
+			}
+		}
+	}
+}
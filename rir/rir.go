@@ -0,0 +1,77 @@
+// Package rir reads the delegated statistics files of the Regional Internet
+// Registries (RIR), which list the IPv4 blocks allocated or assigned to the
+// organizations of each country.
+//
+// Format: https://www.apnic.net/about-apnic/corporate-documents/documents/resource-guidelines/rir-statistics-exchange-format/
+//
+// Files: https://ftp.ripe.net/pub/stats/ripencc/delegated-ripencc-extended-latest
+// (and the same for afrinic, apnic, arin, lacnic).
+package rir
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+
+	"github.com/dolmen-go/cidr2hcmask"
+	"github.com/dolmen-go/cidr2hcmask/internal/match"
+)
+
+// Filter selects the records of a delegated statistics file.
+//
+// Values are matched without regard to case. A record is selected if it matches
+// one of the values of each non-empty list.
+type Filter struct {
+	Countries  []string // ISO 3166 2-letter codes (ex: FR)
+	Registries []string // afrinic, apnic, arin, lacnic, ripencc
+	Statuses   []string // allocated, assigned, available, reserved
+}
+
+// Read imports the IPv4 records of a delegated statistics file (or of its extended
+// variant) matching f. Each record is a start address and a count of addresses,
+// which is often not aligned on a CIDR block, so the records are returned as
+// ranges.
+func Read(r io.Reader, f Filter) ([]cidr2hcmask.IPv4Range, error) {
+	var ranges []cidr2hcmask.IPv4Range
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := strings.Split(line, "|")
+		// Skip the version line (the first field is the version number) and the
+		// summary lines
+		if (line[0] >= '0' && line[0] <= '9') || (len(fields) == 6 && fields[5] == "summary") {
+			continue
+		}
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("line %d: %d fields, at least 7 expected", lineNum, len(fields))
+		}
+		registry, country, typ, start, value, status := fields[0], fields[1], fields[2], fields[3], fields[4], fields[6]
+		if typ != "ipv4" || !match.Fold(f.Registries, registry) || !match.Fold(f.Countries, country) || !match.Fold(f.Statuses, status) {
+			continue
+		}
+
+		addr, err := netip.ParseAddr(start)
+		if err != nil || !addr.Is4() {
+			return nil, fmt.Errorf("line %d: %q: invalid IPv4 address", lineNum, start)
+		}
+		count, err := strconv.ParseUint(value, 10, 32)
+		first := addr.As4()
+		if err != nil || count == 0 || uint64(binary.BigEndian.Uint32(first[:]))+count > 1<<32 {
+			return nil, fmt.Errorf("line %d: %q: invalid count of addresses", lineNum, value)
+		}
+		var last [4]byte
+		binary.BigEndian.PutUint32(last[:], binary.BigEndian.Uint32(first[:])+uint32(count-1))
+		ranges = append(ranges, cidr2hcmask.IPv4Range{First: first, Last: last})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
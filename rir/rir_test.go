@@ -0,0 +1,71 @@
+package rir_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+	"github.com/dolmen-go/cidr2hcmask/rir"
+)
+
+const delegatedExtended = `2|ripencc|1700000000|5|19830705|20231114|+0100
+ripencc|*|ipv4|*|3|summary
+ripencc|*|ipv6|*|1|summary
+ripencc|*|asn|*|1|summary
+# Comment
+ripencc|FR|ipv4|2.0.0.0|1048576|20100712|allocated|f0b8b4b4-0b4b-4b4b-8b4b-4b4b4b4b4b4b
+ripencc|FR|ipv4|5.39.0.0|2048|20120503|allocated|a1
+ripencc|FR|ipv4|5.39.8.0|1280|20120503|allocated|a1
+ripencc|DE|ipv4|2.16.0.0|768|20100712|assigned|b2
+ripencc||ipv4|2.56.200.0|256||available|
+ripencc|FR|ipv6|2001:660::|32|19990810|allocated|c3
+ripencc|FR|asn|1234|1|19930901|allocated|c3
+`
+
+func TestRead(t *testing.T) {
+	for _, tc := range []struct {
+		filter   rir.Filter
+		expected string
+	}{
+		{rir.Filter{Countries: []string{"fr"}}, "2.0.0.0-2.15.255.255,5.39.0.0-5.39.12.255"},
+		{rir.Filter{Countries: []string{"FR", "DE"}, Statuses: []string{"assigned"}}, "2.16.0.0-2.16.2.255"},
+		{rir.Filter{Statuses: []string{"available"}}, "2.56.200.0-2.56.200.255"},
+		{rir.Filter{Registries: []string{"arin"}}, ""},
+	} {
+		ranges, err := rir.Read(strings.NewReader(delegatedExtended), tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cidr2hcmask.NewIPv4Set(ranges...).String(); got != tc.expected {
+			t.Errorf("%+v: got %s, expected %s", tc.filter, got, tc.expected)
+		}
+	}
+}
+
+func TestReadRanges(t *testing.T) {
+	ranges, err := rir.Read(strings.NewReader(delegatedExtended), rir.Filter{Countries: []string{"FR"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Records are not merged
+	if got := fmt.Sprint(ranges); got != "[2.0.0.0-2.15.255.255 5.39.0.0-5.39.7.255 5.39.8.0-5.39.12.255]" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, tc := range []string{
+		"ripencc|FR|ipv4|2.0.0.0|1048576|20100712",
+		"ripencc|FR|ipv4|2.0.0|256|20100712|allocated",
+		"ripencc|FR|ipv4|2.0.0.0|0|20100712|allocated",
+		"ripencc|FR|ipv4|255.255.255.0|257|20100712|allocated",
+		"ripencc|FR|ipv4|2.0.0.0|x|20100712|allocated",
+	} {
+		if _, err := rir.Read(strings.NewReader(tc), rir.Filter{}); err == nil {
+			t.Errorf("%q: error expected", tc)
+		} else {
+			t.Log(err)
+		}
+	}
+}
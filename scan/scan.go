@@ -0,0 +1,153 @@
+// Package scan reads the output files of discovery scanners (nmap XML, masscan
+// JSON) for the IPv4 addresses of the hosts that answered, optionally selected by
+// open port.
+package scan
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+// Filter selects the hosts of a scan.
+//
+// A host is selected if it has one of Ports open (TCP or UDP). With no Ports,
+// every host that is up is selected.
+type Filter struct {
+	Ports []uint16
+}
+
+func (f Filter) matchPort(port uint16) bool {
+	for _, p := range f.Ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+type nmapRun struct {
+	Hosts []struct {
+		Status struct {
+			State string `xml:"state,attr"`
+		} `xml:"status"`
+		Addresses []struct {
+			Addr     string `xml:"addr,attr"`
+			AddrType string `xml:"addrtype,attr"`
+		} `xml:"address"`
+		Ports []struct {
+			PortID uint16 `xml:"portid,attr"`
+			State  struct {
+				State string `xml:"state,attr"`
+			} `xml:"state"`
+		} `xml:"ports>port"`
+	} `xml:"host"`
+}
+
+// ReadNmap imports the IPv4 addresses of the hosts that are up in an nmap XML
+// output file (nmap -oX).
+func ReadNmap(r io.Reader, f Filter) (cidr2hcmask.IPv4Set, error) {
+	var run nmapRun
+	if err := xml.NewDecoder(r).Decode(&run); err != nil {
+		return cidr2hcmask.IPv4Set{}, fmt.Errorf("nmap: %w", err)
+	}
+	var ranges []cidr2hcmask.IPv4Range
+	for _, host := range run.Hosts {
+		if host.Status.State != "up" {
+			continue
+		}
+		if len(f.Ports) > 0 {
+			found := false
+			for _, p := range host.Ports {
+				if p.State.State == "open" && f.matchPort(p.PortID) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		for _, a := range host.Addresses {
+			if a.AddrType != "ipv4" {
+				continue
+			}
+			r, err := hostRange(a.Addr)
+			if err != nil {
+				return cidr2hcmask.IPv4Set{}, fmt.Errorf("nmap: %w", err)
+			}
+			ranges = append(ranges, r)
+		}
+	}
+	return cidr2hcmask.NewIPv4Set(ranges...), nil
+}
+
+type masscanRecord struct {
+	IP    string `json:"ip"`
+	Ports []struct {
+		Port   uint16 `json:"port"`
+		Status string `json:"status"`
+	} `json:"ports"`
+}
+
+// ReadMasscan imports the IPv4 addresses of the hosts of a masscan JSON output
+// file (masscan -oJ). Every host listed has answered.
+//
+// The file is read line by line as masscan writes one record per line, with a
+// trailing comma that older versions also leave on the last record.
+func ReadMasscan(r io.Reader, f Filter) (cidr2hcmask.IPv4Set, error) {
+	// masscan writes the hosts in random order: the set is built at once
+	var ranges []cidr2hcmask.IPv4Range
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		line = strings.TrimSuffix(line, ",")
+		if line == "" || line == "[" || line == "]" || strings.HasPrefix(line, "{finished") {
+			continue
+		}
+		var rec masscanRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return cidr2hcmask.IPv4Set{}, fmt.Errorf("masscan: line %d: %w", lineNum, err)
+		}
+		if len(f.Ports) > 0 {
+			found := false
+			for _, p := range rec.Ports {
+				if p.Status == "open" && f.matchPort(p.Port) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				continue
+			}
+		}
+		if strings.Contains(rec.IP, ":") {
+			continue
+		}
+		r, err := hostRange(rec.IP)
+		if err != nil {
+			return cidr2hcmask.IPv4Set{}, fmt.Errorf("masscan: line %d: %w", lineNum, err)
+		}
+		ranges = append(ranges, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return cidr2hcmask.IPv4Set{}, err
+	}
+	return cidr2hcmask.NewIPv4Set(ranges...), nil
+}
+
+// hostRange returns the range of the single IPv4 address s.
+func hostRange(s string) (cidr2hcmask.IPv4Range, error) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil || !addr.Is4() {
+		return cidr2hcmask.IPv4Range{}, fmt.Errorf("%q: invalid IPv4 address", s)
+	}
+	ip := addr.As4()
+	return cidr2hcmask.IPv4Range{First: ip, Last: ip}, nil
+}
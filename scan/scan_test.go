@@ -0,0 +1,140 @@
+package scan_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask/scan"
+)
+
+const nmapXML = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE nmaprun>
+<nmaprun scanner="nmap" args="nmap -oX - 10.0.0.0/24" start="1700000000" version="7.94" xmloutputversion="1.05">
+<host starttime="1700000000" endtime="1700000001"><status state="up" reason="arp-response" reason_ttl="0"/>
+<address addr="10.0.0.1" addrtype="ipv4"/>
+<address addr="00:11:22:33:44:55" addrtype="mac" vendor="Acme"/>
+<ports><port protocol="tcp" portid="22"><state state="open" reason="syn-ack" reason_ttl="64"/><service name="ssh" method="table" conf="3"/></port>
+<port protocol="tcp" portid="80"><state state="closed" reason="reset" reason_ttl="64"/></port>
+</ports>
+</host>
+<host><status state="up" reason="arp-response"/>
+<address addr="10.0.0.2" addrtype="ipv4"/>
+<ports><port protocol="tcp" portid="80"><state state="open" reason="syn-ack"/></port></ports>
+</host>
+<host><status state="up" reason="arp-response"/>
+<address addr="10.0.0.3" addrtype="ipv4"/>
+</host>
+<host><status state="down" reason="no-response"/>
+<address addr="10.0.0.4" addrtype="ipv4"/>
+</host>
+<host><status state="up" reason="echo-reply"/>
+<address addr="10.0.0.200" addrtype="ipv4"/>
+<ports><port protocol="udp" portid="161"><state state="open" reason="udp-response"/></port></ports>
+</host>
+<runstats><finished time="1700000010" elapsed="10"/><hosts up="4" down="1" total="5"/></runstats>
+</nmaprun>
+`
+
+func TestReadNmap(t *testing.T) {
+	for _, tc := range []struct {
+		filter   scan.Filter
+		expected string
+	}{
+		{scan.Filter{}, "10.0.0.1-10.0.0.3,10.0.0.200"},
+		{scan.Filter{Ports: []uint16{22}}, "10.0.0.1"},
+		{scan.Filter{Ports: []uint16{80, 161}}, "10.0.0.2,10.0.0.200"},
+		{scan.Filter{Ports: []uint16{443}}, ""},
+	} {
+		set, err := scan.ReadNmap(strings.NewReader(nmapXML), tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := set.String(); got != tc.expected {
+			t.Errorf("%v: got %s, expected %s", tc.filter.Ports, got, tc.expected)
+		}
+	}
+}
+
+// Output of masscan 1.0.x (trailing commas and final "finished" line)
+const masscanJSON = `[
+{   "ip": "192.168.1.10",   "timestamp": "1700000000", "ports": [ {"port": 445, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 128} ] },
+{   "ip": "192.168.1.11",   "timestamp": "1700000000", "ports": [ {"port": 22, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 64} ] },
+{   "ip": "192.168.1.12",   "timestamp": "1700000001", "ports": [ {"port": 445, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 128} ] },
+{   "ip": "192.168.1.10",   "timestamp": "1700000001", "ports": [ {"port": 139, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 128} ] },
+{   "ip": "2001:db8::1",   "timestamp": "1700000001", "ports": [ {"port": 445, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 128} ] },
+{finished: 1}
+]
+`
+
+func TestReadMasscan(t *testing.T) {
+	for _, tc := range []struct {
+		filter   scan.Filter
+		expected string
+	}{
+		{scan.Filter{}, "192.168.1.10-192.168.1.12"},
+		{scan.Filter{Ports: []uint16{445}}, "192.168.1.10,192.168.1.12"},
+		{scan.Filter{Ports: []uint16{139, 22}}, "192.168.1.10-192.168.1.11"},
+	} {
+		set, err := scan.ReadMasscan(strings.NewReader(masscanJSON), tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := set.String(); got != tc.expected {
+			t.Errorf("%v: got %s, expected %s", tc.filter.Ports, got, tc.expected)
+		}
+	}
+}
+
+// masscan writes the hosts in random order
+func TestReadMasscanUnsorted(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("[\n")
+	for _, n := range rand.New(rand.NewSource(1)).Perm(65536) {
+		if n>>8 == 128 {
+			continue
+		}
+		fmt.Fprintf(&b, `{   "ip": "10.1.%d.%d",   "timestamp": "1700000000", "ports": [ {"port": 445, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 128} ] },`+"\n", n>>8, n&255)
+	}
+	b.WriteString("{finished: 1}\n]\n")
+	set, err := scan.ReadMasscan(strings.NewReader(b.String()), scan.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := set.String(); got != "10.1.0.0-10.1.127.255,10.1.129.0-10.1.255.255" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestReadNmapUnsorted(t *testing.T) {
+	const xml = `<nmaprun>
+<host><status state="up"/><address addr="10.0.0.9" addrtype="ipv4"/></host>
+<host><status state="up"/><address addr="10.0.0.1" addrtype="ipv4"/></host>
+<host><status state="up"/><address addr="10.0.0.8" addrtype="ipv4"/></host>
+<host><status state="up"/><address addr="10.0.0.2" addrtype="ipv4"/></host>
+<host><status state="up"/><address addr="10.0.0.1" addrtype="ipv4"/></host>
+</nmaprun>`
+	set, err := scan.ReadNmap(strings.NewReader(xml), scan.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := set.String(); got != "10.0.0.1-10.0.0.2,10.0.0.8-10.0.0.9" {
+		t.Errorf("got %s", got)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	if _, err := scan.ReadNmap(strings.NewReader(`<nmaprun><host>`), scan.Filter{}); err == nil {
+		t.Error("nmap: error expected")
+	}
+	if _, err := scan.ReadNmap(strings.NewReader(`<nmaprun><host><status state="up"/><address addr="10.0.0.256" addrtype="ipv4"/></host></nmaprun>`), scan.Filter{}); err == nil {
+		t.Error("nmap: error expected")
+	}
+	if _, err := scan.ReadMasscan(strings.NewReader(`{"ip": "10.0.0.1", "ports": [`), scan.Filter{}); err == nil {
+		t.Error("masscan: error expected")
+	}
+	if _, err := scan.ReadMasscan(strings.NewReader(`{"ip": "10.0.0", "ports": []}`), scan.Filter{}); err == nil {
+		t.Error("masscan: error expected")
+	}
+}
@@ -0,0 +1,279 @@
+package cidr2hcmask
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// IPv4Set is a set of IPv4 addresses. It is normalized as the minimal sorted list of
+// disjoint ranges, so the masks generated from a set cover each address once even if
+// it was built from overlapping networks.
+//
+// The zero value is an empty set.
+type IPv4Set struct {
+	spans []span // sorted, disjoint and not adjacent
+}
+
+// NewIPv4Set returns the set of the addresses of ranges.
+func NewIPv4Set(ranges ...IPv4Range) IPv4Set {
+	spans := make([]span, 0, len(ranges))
+	for _, r := range ranges {
+		spans = append(spans, span{ipv4ToUint32(r.First), ipv4ToUint32(r.Last)})
+	}
+	return IPv4Set{spans: normalizeSpans(spans)}
+}
+
+// NewIPv4SetFromNets returns the set of the addresses of nets.
+func NewIPv4SetFromNets(nets ...IPv4Net) IPv4Set {
+	spans := make([]span, 0, len(nets))
+	for _, net := range nets {
+		spans = append(spans, span{ipv4ToUint32(net.First()), ipv4ToUint32(net.Last())})
+	}
+	return IPv4Set{spans: normalizeSpans(spans)}
+}
+
+// normalizeSpans sorts spans and merges the overlapping and adjacent ones, in place.
+func normalizeSpans(spans []span) []span {
+	if len(spans) == 0 {
+		return nil
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].Lo < spans[j].Lo
+	})
+	merged := spans[:1]
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if uint64(s.Lo) <= uint64(last.Hi)+1 {
+			if s.Hi > last.Hi {
+				last.Hi = s.Hi
+			}
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// AddRange adds the addresses of r to the set.
+//
+// Adding ranges in ascending order is fast. Use [NewIPv4Set] to build a set from many
+// unordered ranges.
+func (s *IPv4Set) AddRange(r IPv4Range) {
+	sp := span{ipv4ToUint32(r.First), ipv4ToUint32(r.Last)}
+	n := len(s.spans)
+	switch {
+	case n == 0 || uint64(sp.Lo) > uint64(s.spans[n-1].Hi)+1:
+		s.spans = append(s.spans, sp)
+	case sp.Lo >= s.spans[n-1].Lo:
+		if sp.Hi > s.spans[n-1].Hi {
+			s.spans[n-1].Hi = sp.Hi
+		}
+	default:
+		s.spans = normalizeSpans(append(s.spans, sp))
+	}
+}
+
+// AddNet adds the addresses of net to the set.
+func (s *IPv4Set) AddNet(net IPv4Net) {
+	s.AddRange(net.Range())
+}
+
+// IsEmpty reports whether the set has no address.
+func (s IPv4Set) IsEmpty() bool {
+	return len(s.spans) == 0
+}
+
+// Size returns the count of addresses in the set.
+func (s IPv4Set) Size() uint64 {
+	var size uint64
+	for _, sp := range s.spans {
+		size += uint64(sp.Hi) - uint64(sp.Lo) + 1
+	}
+	return size
+}
+
+// Contains reports whether ip is in the set.
+func (s IPv4Set) Contains(ip [4]byte) bool {
+	n := ipv4ToUint32(ip)
+	i := sort.Search(len(s.spans), func(i int) bool {
+		return s.spans[i].Hi >= n
+	})
+	return i < len(s.spans) && s.spans[i].Lo <= n
+}
+
+// Ranges returns the minimal sorted list of disjoint ranges of the set.
+func (s IPv4Set) Ranges() []IPv4Range {
+	ranges := make([]IPv4Range, len(s.spans))
+	for i, sp := range s.spans {
+		ranges[i] = IPv4Range{First: uint32ToIPv4(sp.Lo), Last: uint32ToIPv4(sp.Hi)}
+	}
+	return ranges
+}
+
+// Nets returns the minimal sorted list of CIDR blocks of the set (aggregation).
+func (s IPv4Set) Nets() []IPv4Net {
+	var nets []IPv4Net
+	for _, sp := range s.spans {
+		lo, hi := uint64(sp.Lo), uint64(sp.Hi)
+		for lo <= hi {
+			// Largest block aligned on lo that fits in [lo, hi]
+			size := 32
+			if lo != 0 {
+				size = bits.TrailingZeros32(uint32(lo))
+			}
+			for uint64(1)<<size > hi-lo+1 {
+				size--
+			}
+			nets = append(nets, IPv4Net{IP: uint32ToIPv4(uint32(lo)), Bits: 32 - size})
+			lo += uint64(1) << size
+		}
+	}
+	return nets
+}
+
+// String returns the ranges of the set, separated by commas. Single addresses are
+// written without range.
+//
+// String implements interface [fmt.Stringer].
+func (s IPv4Set) String() string {
+	var b strings.Builder
+	for i, r := range s.Ranges() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if r.First == r.Last {
+			fmt.Fprintf(&b, "%d.%d.%d.%d", r.First[0], r.First[1], r.First[2], r.First[3])
+		} else {
+			b.WriteString(r.String())
+		}
+	}
+	return b.String()
+}
+
+// Union returns the set of the addresses that are in s or in other.
+func (s IPv4Set) Union(other IPv4Set) IPv4Set {
+	spans := make([]span, 0, len(s.spans)+len(other.spans))
+	spans = append(append(spans, s.spans...), other.spans...)
+	return IPv4Set{spans: normalizeSpans(spans)}
+}
+
+// Intersect returns the set of the addresses that are both in s and in other.
+func (s IPv4Set) Intersect(other IPv4Set) IPv4Set {
+	var spans []span
+	a, b := s.spans, other.spans
+	for len(a) > 0 && len(b) > 0 {
+		lo, hi := a[0].Lo, a[0].Hi
+		if b[0].Lo > lo {
+			lo = b[0].Lo
+		}
+		if b[0].Hi < hi {
+			hi = b[0].Hi
+		}
+		if lo <= hi {
+			spans = append(spans, span{lo, hi})
+		}
+		if a[0].Hi < b[0].Hi {
+			a = a[1:]
+		} else {
+			b = b[1:]
+		}
+	}
+	return IPv4Set{spans: spans}
+}
+
+// Difference returns the set of the addresses of s that are not in other.
+func (s IPv4Set) Difference(other IPv4Set) IPv4Set {
+	var spans []span
+	b := other.spans
+	for _, sp := range s.spans {
+		lo := uint64(sp.Lo)
+		for len(b) > 0 && b[0].Hi < sp.Lo {
+			b = b[1:]
+		}
+		for _, ex := range b {
+			if ex.Lo > sp.Hi {
+				break
+			}
+			if uint64(ex.Lo) > lo {
+				spans = append(spans, span{uint32(lo), ex.Lo - 1})
+			}
+			lo = uint64(ex.Hi) + 1
+		}
+		if lo <= uint64(sp.Hi) {
+			spans = append(spans, span{uint32(lo), sp.Hi})
+		}
+	}
+	return IPv4Set{spans: spans}
+}
+
+// Hosts returns the set of the addresses of s that are neither the network address
+// (host bits all zeros) nor the broadcast address (host bits all ones) of their
+// subnet of the given prefix length. For bits 31 and 32 (point-to-point links and
+// single hosts, see RFC 3021), s is returned unchanged.
+//
+// The result has a span per subnet, so s must overlap at most
+// [MaxHostsSubnets] subnets.
+//
+// Errors returned (check with [errors.Is]): [ErrTooManySubnets]
+func (s IPv4Set) Hosts(bits int) (IPv4Set, error) {
+	if bits >= 31 {
+		return s, nil
+	}
+	if bits < 0 {
+		bits = 0
+	}
+	hostBits := 32 - bits
+	hostMask := uint64(1)<<hostBits - 1
+	var subnets uint64
+	for _, sp := range s.spans {
+		subnets += uint64(sp.Hi>>hostBits) - uint64(sp.Lo>>hostBits) + 1
+	}
+	if subnets > MaxHostsSubnets {
+		return IPv4Set{}, fmt.Errorf("hosts of %d subnets /%d: %w", subnets, bits, ErrTooManySubnets)
+	}
+	spans := make([]span, 0, subnets)
+	for _, sp := range s.spans {
+		lo, hi := uint64(sp.Lo), uint64(sp.Hi)
+		for base := lo &^ hostMask; base <= hi; base += hostMask + 1 {
+			first, last := base+1, base+hostMask-1
+			if first < lo {
+				first = lo
+			}
+			if last > hi {
+				last = hi
+			}
+			if first <= last {
+				spans = append(spans, span{uint32(first), uint32(last)})
+			}
+		}
+	}
+	return IPv4Set{spans: spans}, nil
+}
+
+// Set2HCMaskFunc calls cb with each hashcat mask of the set of masks that cover
+// exactly once the addresses of s.
+//
+// The octets values that share the same addresses for the next octets are grouped,
+// so the masks of a set built as a cross product of octet ranges are not multiplied.
+func Set2HCMaskFunc(s IPv4Set, cb func(mask string)) {
+	spans2hcmask(s.spans, func(ipmask [4][]string) {
+		expand(ipmask, cb)
+	})
+}
+
+func Set2HCMask(s IPv4Set) []string {
+	var masks []string
+	Set2HCMaskFunc(s, func(mask string) {
+		masks = append(masks, mask)
+	})
+	return masks
+}
+
+func Set2HCMaskWrite(s IPv4Set, w io.Writer) error {
+	return writeMasks(w, func(cb func(string)) {
+		Set2HCMaskFunc(s, cb)
+	})
+}
@@ -0,0 +1,224 @@
+package cidr2hcmask_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/netip"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+// randomSet returns a set of random ranges in 10.0.0.0/22, and the matching bitmap.
+func randomSet(rnd *rand.Rand) (cidr2hcmask.IPv4Set, []bool) {
+	const base = 10 << 24
+	bitmap := make([]bool, 1024)
+	var ranges []cidr2hcmask.IPv4Range
+	for n := rnd.Intn(8); n > 0; n-- {
+		lo := rnd.Intn(1024)
+		hi := lo + rnd.Intn(300)
+		if hi > 1023 {
+			hi = 1023
+		}
+		var r cidr2hcmask.IPv4Range
+		binary.BigEndian.PutUint32(r.First[:], uint32(base+lo))
+		binary.BigEndian.PutUint32(r.Last[:], uint32(base+hi))
+		ranges = append(ranges, r)
+		for i := lo; i <= hi; i++ {
+			bitmap[i] = true
+		}
+	}
+	return cidr2hcmask.NewIPv4Set(ranges...), bitmap
+}
+
+func checkSetBitmap(t *testing.T, op string, s cidr2hcmask.IPv4Set, bitmap []bool) {
+	t.Helper()
+	var size uint64
+	for i, in := range bitmap {
+		if in {
+			size++
+		}
+		if s.Contains([4]byte{10, 0, byte(i >> 8), byte(i)}) != in {
+			t.Errorf("%s: %s: Contains(10.0.%d.%d) != %t", op, s, i>>8, i&255, in)
+			return
+		}
+	}
+	if s.Size() != size {
+		t.Errorf("%s: Size: got %d, expected %d", op, s.Size(), size)
+	}
+	ranges := s.Ranges()
+	for i := 1; i < len(ranges); i++ {
+		prev := binary.BigEndian.Uint32(ranges[i-1].Last[:])
+		if binary.BigEndian.Uint32(ranges[i].First[:]) <= prev+1 {
+			t.Errorf("%s: %s: not normalized", op, s)
+		}
+	}
+}
+
+func TestIPv4SetOperations(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		a, bitmapA := randomSet(rnd)
+		b, bitmapB := randomSet(rnd)
+		checkSetBitmap(t, "A", a, bitmapA)
+
+		union := make([]bool, 1024)
+		inter := make([]bool, 1024)
+		diff := make([]bool, 1024)
+		for i := range union {
+			union[i] = bitmapA[i] || bitmapB[i]
+			inter[i] = bitmapA[i] && bitmapB[i]
+			diff[i] = bitmapA[i] && !bitmapB[i]
+		}
+		checkSetBitmap(t, "Union", a.Union(b), union)
+		checkSetBitmap(t, "Intersect", a.Intersect(b), inter)
+		checkSetBitmap(t, "Difference", a.Difference(b), diff)
+
+		var nets cidr2hcmask.IPv4Set
+		for _, net := range a.Nets() {
+			nets.AddNet(net)
+		}
+		checkSetBitmap(t, "Nets", nets, bitmapA)
+	}
+}
+
+func TestIPv4SetAdd(t *testing.T) {
+	var s cidr2hcmask.IPv4Set
+	for _, cidr := range []string{"10.0.1.0/24", "10.0.0.0/24", "10.0.2.0/23", "10.0.2.128/25", "192.168.0.0/16", "0.0.0.0/32"} {
+		s.AddNet(mustParseCIDR(cidr))
+	}
+	if got := s.String(); got != "0.0.0.0,10.0.0.0-10.0.3.255,192.168.0.0-192.168.255.255" {
+		t.Errorf("got %s", got)
+	}
+	if got := fmt.Sprint(s.Nets()); got != "[0.0.0.0/32 10.0.0.0/22 192.168.0.0/16]" {
+		t.Errorf("Nets: got %s", got)
+	}
+
+	all := cidr2hcmask.NewIPv4SetFromNets(mustParseCIDR("0.0.0.0/0"))
+	if got := all.Size(); got != 1<<32 {
+		t.Errorf("Size: got %d", got)
+	}
+	if got := fmt.Sprint(all.Nets()); got != "[0.0.0.0/0]" {
+		t.Errorf("Nets: got %s", got)
+	}
+	if got := all.Difference(s).Union(s); fmt.Sprint(got.Nets()) != "[0.0.0.0/0]" {
+		t.Errorf("got %s", got)
+	}
+}
+
+// checkSetExpand checks that the masks for s match exactly once the addresses of s.
+func checkSetExpand(t *testing.T, s cidr2hcmask.IPv4Set) (masksCount int) {
+	t.Helper()
+	seen := make(map[uint32]bool)
+	cidr2hcmask.Set2HCMaskFunc(s, func(mask string) {
+		masksCount++
+		HCMaskExpand(mask, func(b []byte) {
+			ip, err := netip.ParseAddr(string(b))
+			if err != nil || !ip.Is4() {
+				t.Errorf("%s: %q: invalid IP", mask, b)
+				return
+			}
+			ip4 := ip.As4()
+			if !s.Contains(ip4) {
+				t.Errorf("%s: %q: not in set", mask, b)
+			}
+			n := binary.BigEndian.Uint32(ip4[:])
+			if seen[n] {
+				t.Errorf("%s: %q: duplicate", mask, b)
+			}
+			seen[n] = true
+		})
+	})
+	if uint64(len(seen)) != s.Size() {
+		t.Errorf("%s: %d addresses matched, expected %d", s, len(seen), s.Size())
+	}
+	return
+}
+
+func TestSet2HCMask(t *testing.T) {
+	rnd := rand.New(rand.NewSource(2))
+	for i := 0; i < 50; i++ {
+		s, _ := randomSet(rnd)
+		checkSetExpand(t, s)
+	}
+}
+
+func TestSet2HCMaskCrossProduct(t *testing.T) {
+	o, err := cidr2hcmask.ParseOctetRanges("10.0-3.7.1-5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s cidr2hcmask.IPv4Set
+	for b := 0; b <= 3; b++ {
+		s.AddRange(cidr2hcmask.IPv4Range{First: [4]byte{10, byte(b), 7, 1}, Last: [4]byte{10, byte(b), 7, 5}})
+	}
+	got := cidr2hcmask.Set2HCMask(s)
+	expected := cidr2hcmask.OctetRanges2HCMask(o)
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+	checkSetExpand(t, s)
+}
+
+func ExampleSet2HCMaskFunc() {
+	var s cidr2hcmask.IPv4Set
+	for _, cidr := range []string{"192.168.0.0/28", "192.168.0.8/29", "192.168.0.16/30"} {
+		net, err := cidr2hcmask.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		s.AddNet(net)
+	}
+	fmt.Println("#", s)
+
+	cidr2hcmask.Set2HCMaskFunc(s, func(mask string) {
+		fmt.Println(mask)
+	})
+
+	// Output:
+	// # 192.168.0.0-192.168.0.19
+	// 01234,012345,123456789,192.168.0.?d
+	// 01234,012345,123456789,192.168.0.1?d
+}
+
+func TestIPv4SetHosts(t *testing.T) {
+	for _, tc := range []struct {
+		set      string
+		bits     int
+		expected string
+	}{
+		{"10.0.0.0/24", 24, "10.0.0.1-10.0.0.254"},
+		{"10.0.0.0/23", 24, "10.0.0.1-10.0.0.254,10.0.1.1-10.0.1.254"},
+		{"10.0.0.0/29", 30, "10.0.0.1-10.0.0.2,10.0.0.5-10.0.0.6"},
+		{"10.0.0.0/24", 31, "10.0.0.0-10.0.0.255"},
+		{"10.0.0.0/24", 32, "10.0.0.0-10.0.0.255"},
+		{"10.0.0.200-10.0.1.10", 24, "10.0.0.200-10.0.0.254,10.0.1.1-10.0.1.10"},
+		{"10.0.0.255-10.0.1.0", 24, ""},
+		{"0.0.0.0/0", 0, "0.0.0.1-255.255.255.254"},
+		{"0.0.0.0/0", 1, "0.0.0.1-127.255.255.254,128.0.0.1-255.255.255.254"},
+	} {
+		var set cidr2hcmask.IPv4Set
+		if r, err := cidr2hcmask.ParseRange(tc.set); err == nil {
+			set = cidr2hcmask.NewIPv4Set(r)
+		} else {
+			set = cidr2hcmask.NewIPv4SetFromNets(mustParseCIDR(tc.set))
+		}
+		got, err := set.Hosts(tc.bits)
+		if err != nil {
+			t.Errorf("%s /%d: %v", tc.set, tc.bits, err)
+		} else if got.String() != tc.expected {
+			t.Errorf("%s /%d: got %s, expected %s", tc.set, tc.bits, got, tc.expected)
+		}
+	}
+
+	// One span per subnet: 2^22 subnets /30 of 0.0.0.0/8
+	set := cidr2hcmask.NewIPv4SetFromNets(mustParseCIDR("0.0.0.0/8"))
+	if _, err := set.Hosts(30); !errors.Is(err, cidr2hcmask.ErrTooManySubnets) {
+		t.Errorf("0.0.0.0/8 /30: got %v, expected ErrTooManySubnets", err)
+	}
+	if got, err := set.Hosts(28); err != nil || got.Size() != 1<<20*14 {
+		t.Errorf("0.0.0.0/8 /28: got %v, %v", got.Size(), err)
+	}
+}
@@ -0,0 +1,75 @@
+package cidr2hcmask
+
+import (
+	"io"
+	"strconv"
+)
+
+// octetLine is the text of a last octet of an address, followed by a newline.
+type octetLine struct {
+	text [4]byte
+	len  uint8
+}
+
+var octetLines = func() (lines [256]octetLine) {
+	for i := range lines {
+		lines[i].len = uint8(copy(lines[i].text[:], strconv.Itoa(i)+"\n"))
+	}
+	return
+}()
+
+// wordlistBufferSize is the size of the buffer of [Set2WordlistWrite].
+const wordlistBufferSize = 64 << 10
+
+// Set2WordlistWrite enumerates the addresses of s to w, in ascending order, one per
+// line in dotted decimal notation without leading zeros: a wordlist for tools that
+// take candidates instead of masks (hashcat --stdin, john --stdin).
+//
+// Output is buffered, with no allocation per address.
+func Set2WordlistWrite(s IPv4Set, w io.Writer) error {
+	buf := make([]byte, wordlistBufferSize)
+	pos := 0
+	var prefix [16]byte // first 3 octets, padded to be copied as a whole
+	for _, sp := range s.spans {
+		for n := uint64(sp.Lo); n <= uint64(sp.Hi); {
+			// The addresses of the same /24 share the first 3 octets
+			p := strconv.AppendUint(prefix[:0], n>>24, 10)
+			p = append(p, '.')
+			p = strconv.AppendUint(p, n>>16&255, 10)
+			p = append(p, '.')
+			p = strconv.AppendUint(p, n>>8&255, 10)
+			p = append(p, '.')
+			prefixLen := len(p)
+
+			last := n | 255
+			if last > uint64(sp.Hi) {
+				last = uint64(sp.Hi)
+			}
+			for o := n & 255; o <= last&255; o++ {
+				if pos+len(prefix)+len(octetLine{}.text) > len(buf) {
+					if _, err := w.Write(buf[:pos]); err != nil {
+						return err
+					}
+					pos = 0
+				}
+				*(*[16]byte)(buf[pos:]) = prefix
+				pos += prefixLen
+				line := &octetLines[o]
+				*(*[4]byte)(buf[pos:]) = line.text
+				pos += int(line.len)
+			}
+			n = last + 1
+		}
+	}
+	if pos > 0 {
+		if _, err := w.Write(buf[:pos]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CIDR2WordlistWrite enumerates the addresses of net to w. See [Set2WordlistWrite].
+func CIDR2WordlistWrite(net IPv4Net, w io.Writer) error {
+	return Set2WordlistWrite(NewIPv4SetFromNets(net), w)
+}
@@ -0,0 +1,105 @@
+package cidr2hcmask_test
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math/rand"
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/dolmen-go/cidr2hcmask"
+)
+
+func ExampleCIDR2WordlistWrite() {
+	net, err := cidr2hcmask.ParseCIDR("192.168.1.254/31")
+	if err != nil {
+		panic(err)
+	}
+
+	cidr2hcmask.CIDR2WordlistWrite(net, os.Stdout)
+
+	// Output:
+	// 192.168.1.254
+	// 192.168.1.255
+}
+
+func checkWordlist(t *testing.T, s cidr2hcmask.IPv4Set) {
+	t.Helper()
+	var out bytes.Buffer
+	if err := cidr2hcmask.Set2WordlistWrite(s, &out); err != nil {
+		t.Fatal(err)
+	}
+	var count uint64
+	prev := -1
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		ip, err := netip.ParseAddr(scanner.Text())
+		if err != nil || !ip.Is4() || ip.String() != scanner.Text() {
+			t.Fatalf("%q: invalid IP", scanner.Text())
+		}
+		ip4 := ip.As4()
+		if !s.Contains(ip4) {
+			t.Errorf("%s: not in set", ip)
+		}
+		n := int(ip4[0])<<24 | int(ip4[1])<<16 | int(ip4[2])<<8 | int(ip4[3])
+		if n <= prev {
+			t.Fatalf("%s: not in ascending order", ip)
+		}
+		prev = n
+		count++
+	}
+	if count != s.Size() {
+		t.Errorf("%s: %d addresses, expected %d", s, count, s.Size())
+	}
+}
+
+func TestSet2WordlistWrite(t *testing.T) {
+	checkWordlist(t, cidr2hcmask.IPv4Set{})
+	checkWordlist(t, cidr2hcmask.NewIPv4SetFromNets(mustParseCIDR("10.0.0.0/14")))
+	checkWordlist(t, cidr2hcmask.NewIPv4SetFromNets(mustParseCIDR("255.255.0.0/16"), mustParseCIDR("0.0.0.0/20")))
+	rnd := rand.New(rand.NewSource(5))
+	for i := 0; i < 20; i++ {
+		set, _ := randomSet(rnd)
+		checkWordlist(t, set)
+	}
+}
+
+type failWriter struct{ n int }
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, io.ErrShortWrite
+	}
+	w.n--
+	return len(p), nil
+}
+
+func TestSet2WordlistWriteError(t *testing.T) {
+	set := cidr2hcmask.NewIPv4SetFromNets(mustParseCIDR("10.0.0.0/16"))
+	for n := 0; n < 3; n++ {
+		if err := cidr2hcmask.Set2WordlistWrite(set, &failWriter{n: n}); err != io.ErrShortWrite {
+			t.Errorf("%d: got %v", n, err)
+		}
+	}
+}
+
+func BenchmarkSet2WordlistWrite(b *testing.B) {
+	set := cidr2hcmask.NewIPv4SetFromNets(mustParseCIDR("10.0.0.0/16"))
+	var out countWriter
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := cidr2hcmask.Set2WordlistWrite(set, &out); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.SetBytes(int64(out) / int64(b.N))
+}
+
+type countWriter int64
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	*w += countWriter(len(p))
+	return len(p), nil
+}